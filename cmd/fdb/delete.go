@@ -9,6 +9,7 @@ import (
 )
 
 var confirmed = false
+var breakStaleLock = false
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
@@ -49,28 +50,28 @@ var deleteEverythingCmd = &cobra.Command{
 func runDeleteEverything(cmd *cobra.Command, args []string) {
 	folder, err := internal.ConfigureFolder()
 	tracelog.ErrorLogger.FatalOnError(err)
-	internal.DeleteEverything(folder, confirmed, args)
+	internal.DeleteEverything(folder, confirmed, breakStaleLock, args)
 }
 
 func runDeleteBefore(cmd *cobra.Command, args []string) {
 	folder, err := internal.ConfigureFolder()
 	tracelog.ErrorLogger.FatalOnError(err)
 
-	internal.HandleDeleteBefore(folder, args, confirmed, isFullBackup, GetLessFunc(folder))
+	internal.HandleDeleteBefore(folder, args, confirmed, breakStaleLock, isFullBackup, GetLessFunc(folder))
 }
 
 func runDeleteRetain(cmd *cobra.Command, args []string) {
 	folder, err := internal.ConfigureFolder()
 	tracelog.ErrorLogger.FatalOnError(err)
 
-	internal.HandleDeleteRetain(folder, args, confirmed, isFullBackup, GetLessFunc(folder))
+	internal.HandleDeleteRetain(folder, args, confirmed, breakStaleLock, isFullBackup, GetLessFunc(folder))
 }
 
 func runDeleteRetainAfter(cmd *cobra.Command, args []string) {
 	folder, err := internal.ConfigureFolder()
 	tracelog.ErrorLogger.FatalOnError(err)
 
-	internal.HandleDeletaRetainAfter(folder, args, confirmed, isFullBackup, GetLessFunc(folder))
+	internal.HandleDeletaRetainAfter(folder, args, confirmed, breakStaleLock, isFullBackup, GetLessFunc(folder))
 }
 
 func isFullBackup(object storage.Object) bool {
@@ -82,6 +83,8 @@ func init() {
 	deleteRetainCmd.Flags().StringP("after", "a", "", "Set the time after which retain backups")
 	deleteCmd.AddCommand(deleteBeforeCmd, deleteRetainCmd, deleteEverythingCmd)
 	deleteCmd.PersistentFlags().BoolVar(&confirmed, internal.ConfirmFlag, false, "Confirms backup deletion")
+	deleteCmd.PersistentFlags().BoolVar(&breakStaleLock, internal.BreakStaleLockFlag, false,
+		internal.BreakStaleLockDescription)
 }
 
 func GetLessFunc(folder storage.Folder) func(object1, object2 storage.Object) bool {
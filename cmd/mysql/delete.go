@@ -13,6 +13,7 @@ import (
 )
 
 var confirmed = false
+var breakStaleLock = false
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
@@ -46,7 +47,7 @@ var deleteEverythingCmd = &cobra.Command{
 func runDeleteEverything(cmd *cobra.Command, args []string) {
 	folder, err := internal.ConfigureFolder()
 	tracelog.ErrorLogger.FatalOnError(err)
-	internal.DeleteEverything(folder, confirmed, args)
+	internal.DeleteEverything(folder, confirmed, breakStaleLock, args)
 }
 
 func runDeleteBefore(cmd *cobra.Command, args []string) {
@@ -55,7 +56,7 @@ func runDeleteBefore(cmd *cobra.Command, args []string) {
 	isFullBackup := func(object storage.Object) bool {
 		return IsFullBackup(folder, object)
 	}
-	internal.HandleDeleteBefore(folder, args, confirmed, isFullBackup, GetLessFunc(folder))
+	internal.HandleDeleteBefore(folder, args, confirmed, breakStaleLock, isFullBackup, GetLessFunc(folder))
 }
 
 func runDeleteRetain(cmd *cobra.Command, args []string) {
@@ -64,13 +65,15 @@ func runDeleteRetain(cmd *cobra.Command, args []string) {
 	isFullBackup := func(object storage.Object) bool {
 		return IsFullBackup(folder, object)
 	}
-	internal.HandleDeleteRetain(folder, args, confirmed, isFullBackup, GetLessFunc(folder))
+	internal.HandleDeleteRetain(folder, args, confirmed, breakStaleLock, isFullBackup, GetLessFunc(folder))
 }
 
 func init() {
 	Cmd.AddCommand(deleteCmd)
 	deleteCmd.AddCommand(deleteBeforeCmd, deleteRetainCmd, deleteEverythingCmd)
 	deleteCmd.PersistentFlags().BoolVar(&confirmed, internal.ConfirmFlag, false, "Confirms backup deletion")
+	deleteCmd.PersistentFlags().BoolVar(&breakStaleLock, internal.BreakStaleLockFlag, false,
+		internal.BreakStaleLockDescription)
 }
 
 func IsFullBackup(folder storage.Folder, object storage.Object) bool {
@@ -0,0 +1,33 @@
+package pg
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal"
+)
+
+const (
+	BackupDiffShortDescription = "Prints files which differ between two backups"
+	BackupDiffLongDescription  = "Compares file lists of two backups and prints files added (+), removed (-) " +
+		"and changed (~) in the newer backup. Only sentinels are downloaded."
+)
+
+// backupDiffCmd represents the backupDiff command
+var backupDiffCmd = &cobra.Command{
+	Use:   "backup-diff older_backup_name newer_backup_name",
+	Short: BackupDiffShortDescription,
+	Long:  BackupDiffLongDescription,
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		folder, err := internal.ConfigureFolder()
+		tracelog.ErrorLogger.FatalOnError(err)
+		err = internal.HandleBackupDiff(folder, args[0], args[1], os.Stdout)
+		tracelog.ErrorLogger.FatalOnError(err)
+	},
+}
+
+func init() {
+	Cmd.AddCommand(backupDiffCmd)
+}
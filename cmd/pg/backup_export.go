@@ -0,0 +1,58 @@
+package pg
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/utility"
+)
+
+const (
+	BackupExportShortDescription = "Writes all objects of a backup to a single archive file"
+	BackupExportLongDescription  = "Writes sentinel, metadata and tars of a backup as stored, without decryption, " +
+		"to a single tar file with an index, e.g. to move the backup on physical media. Use backup-import to put it to storage. " +
+		"WAL segments are not exported, copy WAL archive separately to restore the backup."
+	BackupImportShortDescription = "Puts objects of an archive made by backup-export to storage"
+)
+
+var (
+	// backupExportCmd represents the backupExport command
+	backupExportCmd = &cobra.Command{
+		Use:   "backup-export backup_name destination_file",
+		Short: BackupExportShortDescription,
+		Long:  BackupExportLongDescription,
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			folder, err := internal.ConfigureFolder()
+			tracelog.ErrorLogger.FatalOnError(err)
+			file, err := os.Create(args[1])
+			tracelog.ErrorLogger.FatalOnError(err)
+			defer utility.LoggedClose(file, "")
+			err = internal.ExportBackup(folder, args[0], file)
+			tracelog.ErrorLogger.FatalOnError(err)
+		},
+	}
+
+	// backupImportCmd represents the backupImport command
+	backupImportCmd = &cobra.Command{
+		Use:   "backup-import source_file",
+		Short: BackupImportShortDescription,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			folder, err := internal.ConfigureFolder()
+			tracelog.ErrorLogger.FatalOnError(err)
+			file, err := os.Open(args[0])
+			tracelog.ErrorLogger.FatalOnError(err)
+			defer utility.LoggedClose(file, "")
+			_, err = internal.ImportBackup(folder, file)
+			tracelog.ErrorLogger.FatalOnError(err)
+		},
+	}
+)
+
+func init() {
+	Cmd.AddCommand(backupExportCmd)
+	Cmd.AddCommand(backupImportCmd)
+}
@@ -1,6 +1,8 @@
 package pg
 
 import (
+	"os"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/wal-g/storages/storage"
@@ -16,12 +18,18 @@ For information about pattern syntax view: https://golang.org/pkg/path/filepath/
 	RestoreSpecDescription        = "Path to file containing tablespace restore specification"
 	ReverseDeltaUnpackDescription = "Unpack delta backups in reverse order (beta feature)"
 	SkipRedundantTarsDescription  = "Skip tars with no useful data (requires reverse delta unpack)"
+	VerifyOnlyDescription         = `Only read and checksum every file of the backup without writing it,
+destination_directory is ignored`
+	ExcludeTablespaceDescription = "Do not restore files of tablespace with given OID, can be repeated"
 )
 
 var fileMask string
 var restoreSpec string
 var reverseDeltaUnpack bool
 var skipRedundantTars bool
+var verifyOnly bool
+var excludedTablespaces []string
+var fetchNoProgress bool
 
 var backupFetchCmd = &cobra.Command{
 	Use:   "backup-fetch destination_directory backup_name",
@@ -34,13 +42,17 @@ var backupFetchCmd = &cobra.Command{
 		var pgFetcher func(folder storage.Folder, backup internal.Backup)
 		reverseDeltaUnpack = reverseDeltaUnpack || viper.GetBool(internal.UseReverseUnpackSetting)
 		skipRedundantTars = skipRedundantTars || viper.GetBool(internal.SkipRedundantTarsSetting)
-		if reverseDeltaUnpack {
-			pgFetcher = internal.GetPgFetcherNew(args[0], fileMask, restoreSpec, skipRedundantTars)
+		if verifyOnly {
+			pgFetcher = internal.GetPgVerifyingFetcher()
+		} else if reverseDeltaUnpack {
+			pgFetcher = internal.GetPgFetcherNew(args[0], fileMask, restoreSpec, skipRedundantTars, excludedTablespaces)
 		} else {
-			pgFetcher = internal.GetPgFetcherOld(args[0], fileMask, restoreSpec)
+			pgFetcher = internal.GetPgFetcherOld(args[0], fileMask, restoreSpec, excludedTablespaces)
 		}
 
+		internal.FetchProgressBar = internal.NewProgressBar(os.Stdout, fetchNoProgress, false, 0, 0)
 		internal.HandleBackupFetch(folder, args[1], pgFetcher)
+		internal.FetchProgressBar.Finish()
 	},
 }
 
@@ -51,5 +63,8 @@ func init() {
 		false, ReverseDeltaUnpackDescription)
 	backupFetchCmd.Flags().BoolVar(&skipRedundantTars, "skip-redundant-tars",
 		false, SkipRedundantTarsDescription)
+	backupFetchCmd.Flags().BoolVar(&verifyOnly, "verify-only", false, VerifyOnlyDescription)
+	backupFetchCmd.Flags().StringSliceVar(&excludedTablespaces, "exclude-tablespace", nil, ExcludeTablespaceDescription)
+	backupFetchCmd.Flags().BoolVar(&fetchNoProgress, noProgressFlag, false, noProgressDescription)
 	Cmd.AddCommand(backupFetchCmd)
 }
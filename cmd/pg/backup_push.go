@@ -38,7 +38,8 @@ var (
 			if useRatingComposer {
 				tarBallComposerType = internal.RatingComposer
 			}
-			internal.HandleBackupPush(uploader, args[0], permanent, fullBackup, verifyPageChecksums, storeAllCorruptBlocks, tarBallComposerType)
+			internal.HandleBackupPush(uploader, args[0], permanent, fullBackup, verifyPageChecksums, storeAllCorruptBlocks,
+				breakStaleLock, tarBallComposerType)
 		},
 	}
 	permanent             = false
@@ -57,4 +58,5 @@ func init() {
 	backupPushCmd.Flags().BoolVarP(&storeAllCorruptBlocks, StoreAllCorruptBlocksFlag, StoreAllCorruptBlocksShorthand,
 		false, "Store all corrupt blocks found during page checksum verification")
 	backupPushCmd.Flags().BoolVarP(&useRatingComposer, UseRatingComposer, UseRatingComposerShortHand, false, "Use rating tar composer (beta)")
+	addBreakStaleLockFlag(backupPushCmd.Flags())
 }
@@ -0,0 +1,37 @@
+package pg
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal"
+)
+
+const (
+	BackupShowShortDescription = "Prints metadata of a backup"
+	BackupShowLongDescription  = "Prints sentinel and metadata of a backup as JSON without downloading the backup data. " +
+		"Use LATEST to show the newest backup."
+)
+
+var (
+	// backupShowCmd represents the backupShow command
+	backupShowCmd = &cobra.Command{
+		Use:   "backup-show backup_name",
+		Short: BackupShowShortDescription,
+		Long:  BackupShowLongDescription,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			folder, err := internal.ConfigureFolder()
+			tracelog.ErrorLogger.FatalOnError(err)
+			err = internal.HandleBackupShow(folder, args[0], os.Stdout, backupShowPretty)
+			tracelog.ErrorLogger.FatalOnError(err)
+		},
+	}
+	backupShowPretty = false
+)
+
+func init() {
+	backupShowCmd.Flags().BoolVar(&backupShowPretty, "pretty", false, "Prints more readable JSON")
+	Cmd.AddCommand(backupShowCmd)
+}
@@ -0,0 +1,33 @@
+package pg
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal"
+)
+
+const (
+	BackupValidateShortDescription = "Checks that all tars referenced by backup sentinel exist"
+	BackupValidateLongDescription  = "Reads the backup sentinel and prints tars it references which are missing in storage. " +
+		"Fails if some tars are missing. Tars are not downloaded."
+)
+
+// backupValidateCmd represents the backupValidate command
+var backupValidateCmd = &cobra.Command{
+	Use:   "backup-validate backup_name",
+	Short: BackupValidateShortDescription,
+	Long:  BackupValidateLongDescription,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		folder, err := internal.ConfigureFolder()
+		tracelog.ErrorLogger.FatalOnError(err)
+		err = internal.HandleBackupValidate(folder, args[0], os.Stdout)
+		tracelog.ErrorLogger.FatalOnError(err)
+	},
+}
+
+func init() {
+	Cmd.AddCommand(backupValidateCmd)
+}
@@ -1,7 +1,12 @@
 package pg
 
 import (
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
+	"github.com/wal-g/tracelog"
 	"github.com/wal-g/wal-g/internal"
 )
 
@@ -25,13 +30,89 @@ const (
 	withoutHistoryFlag        = "without-history"
 	withoutHistoryShorthand   = "w"
 	withoutHistoryDescription = "Copy backup without history"
+
+	renameRuleFlag        = "rename-rule"
+	renameRuleDescription = "Name of rename rule from " + internal.CopyRenameRulesSetting + " applied to copied objects"
+
+	noProgressFlag        = "no-progress"
+	noProgressDescription = "Do not show progress bar"
+
+	forceProgressFlag        = "force-progress"
+	forceProgressDescription = "Show progress bar even if stdout is not a terminal"
+
+	modifiedSinceFlag        = "modified-since"
+	modifiedSinceDescription = "Copy only objects modified at or after given time (RFC3339), e.g. time of the previous sync"
+
+	notFoundRetriesFlag        = "not-found-retries"
+	notFoundRetriesDescription = "Retry reading source objects which are not found yet, e.g. in eventually consistent storages"
+
+	collisionPolicyFlag = "collision-policy"
+
+	successMarkerFlag        = "success-marker"
+	successMarkerDescription = "Put " + internal.CopySuccessMarkerName + " object to the destination after all objects are copied"
+
+	repairFlag        = "repair"
+	repairDescription = "Copy only objects which are missing in destination or differ from source by size or content"
+
+	syncFlag        = "sync"
+	syncDescription = "Copy all objects missing or changed in destination keeping their names, like rsync"
+
+	deleteFlag        = "delete"
+	deleteDescription = "With --" + syncFlag + ", delete destination objects absent in source"
+
+	forceFlag        = "force"
+	forceDescription = "Copy even if there are more objects than " + internal.CopyMaxObjectsSetting
+
+	flattenFlag        = "flatten"
+	flattenDescription = "Copy all objects to the top level of destination dropping their subfolders, " +
+		"objects with the same name are handled by --" + collisionPolicyFlag
+
+	nameRegexFlag        = "name-regex"
+	nameRegexDescription = "Copy only objects whose whole name relative to the source storage matches this regular expression"
+
+	eventsFlag        = "events"
+	eventsDescription = "Write the result of every object as a JSON line to this file, - for stdout"
+
+	failureManifestFlag        = "failure-manifest"
+	failureManifestDescription = "If copy fails, write objects which were not copied to this file"
+
+	retryFailedFlag        = "retry-failed"
+	retryFailedDescription = "Copy only objects listed in the --" + failureManifestFlag + " file of previous run"
+
+	rampUpFlag        = "ramp-up"
+	rampUpDescription = "Start copying with few parallel requests and reach full concurrency after given duration, e.g. 30s"
+
+	sizeOrderFlag        = "size-order"
+	sizeOrderDescription = "Copy objects ordered by size: " + internal.CopyOrderLargestFirst + " or " + internal.CopyOrderSmallestFirst
 )
 
 var (
-	backupName     string
-	fromConfigFile string
-	toConfigFile   string
-	withoutHistory = false
+	backupName      string
+	fromConfigFile  string
+	toConfigFile    string
+	withoutHistory  = false
+	renameRule      string
+	noProgress      bool
+	forceProgress   bool
+	modifiedSince   string
+	notFoundRetries int
+	collisionPolicy string
+	successMarker   bool
+	repair          bool
+	sizeOrder       string
+	syncFolders     bool
+	deleteExtra     bool
+	forceCopy       bool
+	flatten         bool
+	rampUp          time.Duration
+	failureManifest string
+	retryFailed     string
+	nameRegex       string
+	eventsPath      string
+
+	// syncIncompatibleFlags change which objects are copied or how they are named, SyncFolder doesn't support them
+	syncIncompatibleFlags = []string{renameRuleFlag, modifiedSinceFlag, successMarkerFlag, repairFlag,
+		sizeOrderFlag, collisionPolicyFlag}
 
 	backupCopyCmd = &cobra.Command{
 		Use:   backupCopyUsage,
@@ -43,7 +124,65 @@ var (
 )
 
 func runBackupCopy(cmd *cobra.Command, args []string) {
-	internal.HandleCopy(fromConfigFile, toConfigFile, backupName, withoutHistory)
+	if deleteExtra && !syncFolders {
+		tracelog.ErrorLogger.Fatalf("--%s can be used only with --%s\n", deleteFlag, syncFlag)
+	}
+	if syncFolders && flatten {
+		tracelog.ErrorLogger.Fatalf("--%s keeps object names and can't be used with --%s\n", syncFlag, flattenFlag)
+	}
+	if retryFailed != "" && (syncFolders || backupName != "") {
+		tracelog.ErrorLogger.Fatalf("--%s can't be used with --%s or --%s\n", retryFailedFlag, syncFlag, backupNameFlag)
+	}
+	if syncFolders && backupName != "" {
+		tracelog.ErrorLogger.Fatalf("--%s copies all objects and can't be used with --%s\n", syncFlag, backupNameFlag)
+	}
+	if syncFolders && nameRegex != "" {
+		tracelog.ErrorLogger.Fatalf("--%s copies all objects and can't be used with --%s\n", syncFlag, nameRegexFlag)
+	}
+	if syncFolders {
+		for _, flag := range syncIncompatibleFlags {
+			if cmd.Flags().Changed(flag) {
+				tracelog.ErrorLogger.Fatalf("--%s copies objects missing or changed in destination as is "+
+					"and can't be used with --%s\n", syncFlag, flag)
+			}
+		}
+	}
+	var since time.Time
+	if modifiedSince != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, modifiedSince)
+		tracelog.ErrorLogger.FatalfOnError("Failed to parse --"+modifiedSinceFlag+": %v\n", err)
+	}
+	var regex *regexp.Regexp
+	if nameRegex != "" {
+		var err error
+		regex, err = internal.CompileCopyNameRegex(nameRegex)
+		tracelog.ErrorLogger.FatalOnError(err)
+	}
+	internal.HandleCopy(internal.CopySettings{
+		FromConfigFile:  fromConfigFile,
+		ToConfigFile:    toConfigFile,
+		BackupName:      backupName,
+		WithoutHistory:  withoutHistory,
+		RenameRule:      renameRule,
+		NoProgress:      noProgress,
+		ForceProgress:   forceProgress,
+		ModifiedSince:   since,
+		NotFoundRetries: notFoundRetries,
+		CollisionPolicy: collisionPolicy,
+		SuccessMarker:   successMarker,
+		Repair:          repair,
+		SizeOrder:       sizeOrder,
+		Sync:            syncFolders,
+		Delete:          deleteExtra,
+		Force:           forceCopy,
+		Flatten:         flatten,
+		RampUp:          rampUp,
+		FailureManifest: failureManifest,
+		RetryFailed:     retryFailed,
+		NameRegex:       regex,
+		EventsPath:      eventsPath,
+	})
 }
 
 func init() {
@@ -53,6 +192,25 @@ func init() {
 	backupCopyCmd.Flags().StringVarP(&toConfigFile, toFlag, toShorthand, "", toDescription)
 	backupCopyCmd.Flags().StringVarP(&fromConfigFile, fromFlag, fromShorthand, "", fromDescription)
 	backupCopyCmd.Flags().BoolVarP(&withoutHistory, withoutHistoryFlag, withoutHistoryShorthand, false, withoutHistoryDescription)
+	backupCopyCmd.Flags().StringVar(&renameRule, renameRuleFlag, "", renameRuleDescription)
+	backupCopyCmd.Flags().BoolVar(&noProgress, noProgressFlag, false, noProgressDescription)
+	backupCopyCmd.Flags().BoolVar(&forceProgress, forceProgressFlag, false, forceProgressDescription)
+	backupCopyCmd.Flags().StringVar(&modifiedSince, modifiedSinceFlag, "", modifiedSinceDescription)
+	backupCopyCmd.Flags().IntVar(&notFoundRetries, notFoundRetriesFlag, 0, notFoundRetriesDescription)
+	backupCopyCmd.Flags().StringVar(&collisionPolicy, collisionPolicyFlag, internal.CopyCollisionOverwrite,
+		"What to do with objects copied to the same target name: "+strings.Join(internal.CopyCollisionPolicies, ", "))
+	backupCopyCmd.Flags().BoolVar(&successMarker, successMarkerFlag, false, successMarkerDescription)
+	backupCopyCmd.Flags().BoolVar(&repair, repairFlag, false, repairDescription)
+	backupCopyCmd.Flags().StringVar(&sizeOrder, sizeOrderFlag, "", sizeOrderDescription)
+	backupCopyCmd.Flags().BoolVar(&syncFolders, syncFlag, false, syncDescription)
+	backupCopyCmd.Flags().BoolVar(&deleteExtra, deleteFlag, false, deleteDescription)
+	backupCopyCmd.Flags().BoolVar(&forceCopy, forceFlag, false, forceDescription)
+	backupCopyCmd.Flags().BoolVar(&flatten, flattenFlag, false, flattenDescription)
+	backupCopyCmd.Flags().DurationVar(&rampUp, rampUpFlag, 0, rampUpDescription)
+	backupCopyCmd.Flags().StringVar(&failureManifest, failureManifestFlag, "", failureManifestDescription)
+	backupCopyCmd.Flags().StringVar(&retryFailed, retryFailedFlag, "", retryFailedDescription)
+	backupCopyCmd.Flags().StringVar(&nameRegex, nameRegexFlag, "", nameRegexDescription)
+	backupCopyCmd.Flags().StringVar(&eventsPath, eventsFlag, "", eventsDescription)
 
 	backupCopyCmd.MarkFlagFilename(toConfigFile)
 	backupCopyCmd.MarkFlagFilename(fromConfigFile)
@@ -52,7 +52,7 @@ func runDeleteBefore(cmd *cobra.Command, args []string) {
 	isFullBackup := func(object storage.Object) bool {
 		return postgresIsFullBackup(folder, object)
 	}
-	internal.HandleDeleteBefore(folder, args, confirmed, isFullBackup, postgresLess)
+	internal.HandleDeleteBefore(folder, args, confirmed, breakStaleLock, isFullBackup, postgresLess)
 }
 
 func runDeleteRetain(cmd *cobra.Command, args []string) {
@@ -61,13 +61,13 @@ func runDeleteRetain(cmd *cobra.Command, args []string) {
 	isFullBackup := func(object storage.Object) bool {
 		return postgresIsFullBackup(folder, object)
 	}
-	internal.HandleDeleteRetain(folder, args, confirmed, isFullBackup, postgresLess)
+	internal.HandleDeleteRetain(folder, args, confirmed, breakStaleLock, isFullBackup, postgresLess)
 }
 
 func runDeleteEverything(cmd *cobra.Command, args []string) {
 	folder, err := internal.ConfigureFolder()
 	tracelog.ErrorLogger.FatalOnError(err)
-	internal.DeleteEverything(folder, confirmed, args)
+	internal.DeleteEverything(folder, confirmed, breakStaleLock, args)
 }
 
 func init() {
@@ -75,6 +75,7 @@ func init() {
 
 	deleteCmd.AddCommand(deleteRetainCmd, deleteBeforeCmd, deleteEverythingCmd)
 	deleteCmd.PersistentFlags().BoolVar(&confirmed, internal.ConfirmFlag, false, "Confirms backup deletion")
+	addBreakStaleLockFlag(deleteCmd.PersistentFlags())
 }
 
 // TODO: create postgres part and move it there, if it will be needed
@@ -26,6 +26,8 @@ var (
 		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			err := internal.AssertRequiredSettingsSet()
 			tracelog.ErrorLogger.FatalOnError(err)
+			err = internal.ValidateSettings()
+			tracelog.ErrorLogger.FatalOnError(err)
 		},
 	}
 )
@@ -0,0 +1,34 @@
+package pg
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal"
+)
+
+const (
+	ShowConfigUsage            = "show-config"
+	ShowConfigShortDescription = "Show effective configuration"
+	ShowConfigLongDescription  = "Show settings as WAL-G sees them after merging flags, environment, " +
+		"config file and defaults, with secrets redacted"
+)
+
+// showConfigCmd represents the show-config command
+var showConfigCmd = &cobra.Command{
+	Use:   ShowConfigUsage,
+	Short: ShowConfigShortDescription,
+	Long:  ShowConfigLongDescription,
+	Args:  cobra.NoArgs,
+	// settings validation is skipped, settings applied at startup by internal.Configure still have to be valid
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		err := internal.HandleShowConfig(os.Stdout)
+		tracelog.ErrorLogger.FatalOnError(err)
+	},
+}
+
+func init() {
+	Cmd.AddCommand(showConfigCmd)
+}
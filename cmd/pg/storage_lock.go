@@ -0,0 +1,13 @@
+package pg
+
+import (
+	"github.com/spf13/pflag"
+	"github.com/wal-g/wal-g/internal"
+)
+
+var breakStaleLock = false
+
+// addBreakStaleLockFlag adds the flag of commands which take the storage lock, see internal.WithConfiguredStorageLock
+func addBreakStaleLockFlag(flags *pflag.FlagSet) {
+	flags.BoolVar(&breakStaleLock, internal.BreakStaleLockFlag, false, internal.BreakStaleLockDescription)
+}
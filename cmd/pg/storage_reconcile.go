@@ -0,0 +1,52 @@
+package pg
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal"
+)
+
+const (
+	StorageReconcileShortDescription = "Copies objects missing in some of mirrored storages"
+	StorageReconcileLongDescription  = "Lists storages configured in given config files and copies every object " +
+		"missing in some of them from the first storage which has it, so that all storages converge. " +
+		"Prints the plan as '<source config> -> <destination config>: <object>' lines."
+
+	reconcileConfigFlag        = "storage-config"
+	reconcileConfigDescription = "Storage config file, give at least two"
+
+	reconcileDryRunFlag        = "dry-run"
+	reconcileDryRunDescription = "Only print the plan"
+)
+
+var (
+	reconcileConfigFiles []string
+	reconcileDryRun      bool
+
+	// storageReconcileCmd represents the storageReconcile command
+	storageReconcileCmd = &cobra.Command{
+		Use:   "storage-reconcile --storage-config first.yaml --storage-config second.yaml",
+		Short: StorageReconcileShortDescription,
+		Long:  StorageReconcileLongDescription,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			folders := make([]storage.Folder, 0, len(reconcileConfigFiles))
+			for _, configFile := range reconcileConfigFiles {
+				folder, err := internal.ConfigureFolderFromConfig(configFile)
+				tracelog.ErrorLogger.FatalOnError(err)
+				folders = append(folders, folder)
+			}
+			err := internal.HandleStorageReconcile(folders, reconcileConfigFiles, reconcileDryRun, os.Stdout)
+			tracelog.ErrorLogger.FatalOnError(err)
+		},
+	}
+)
+
+func init() {
+	Cmd.AddCommand(storageReconcileCmd)
+	storageReconcileCmd.Flags().StringArrayVar(&reconcileConfigFiles, reconcileConfigFlag, nil, reconcileConfigDescription)
+	storageReconcileCmd.Flags().BoolVar(&reconcileDryRun, reconcileDryRunFlag, false, reconcileDryRunDescription)
+}
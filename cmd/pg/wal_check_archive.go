@@ -0,0 +1,42 @@
+package pg
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal"
+)
+
+const (
+	WalCheckArchiveShortDescription = "Checks WAL archive for gaps and unreadable segments"
+	WalCheckArchiveLongDescription  = "Lists WAL segments in storage and reports the first gap in a timeline. " +
+		"With --read, also downloads every segment and reports segments which can't be decompressed or are empty. " +
+		"Unlike wal-verify, does not need a connection to the cluster."
+
+	readSegmentsFlag        = "read"
+	readSegmentsDescription = "Download, decrypt and decompress every segment"
+)
+
+var (
+	readSegments bool
+
+	// walCheckArchiveCmd represents the walCheckArchive command
+	walCheckArchiveCmd = &cobra.Command{
+		Use:   "wal-check-archive",
+		Short: WalCheckArchiveShortDescription,
+		Long:  WalCheckArchiveLongDescription,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			folder, err := internal.ConfigureFolder()
+			tracelog.ErrorLogger.FatalOnError(err)
+			err = internal.HandleWalArchiveCheck(folder, readSegments, os.Stdout)
+			tracelog.ErrorLogger.FatalOnError(err)
+		},
+	}
+)
+
+func init() {
+	Cmd.AddCommand(walCheckArchiveCmd)
+	walCheckArchiveCmd.Flags().BoolVar(&readSegments, readSegmentsFlag, false, readSegmentsDescription)
+}
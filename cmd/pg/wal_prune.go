@@ -0,0 +1,44 @@
+package pg
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal"
+)
+
+const (
+	WalPruneShortDescription = "Deletes WAL older than the oldest backup"
+	WalPruneLongDescription  = "Deletes WAL segments which are not needed to restore any backup in storage " +
+		"and prints names of deleted objects. Timeline history files are kept."
+	walPruneDryRunDescription = "Only print WAL objects which would be deleted"
+)
+
+var (
+	// walPruneCmd represents the walPrune command
+	walPruneCmd = &cobra.Command{
+		Use:   "wal-prune",
+		Short: WalPruneShortDescription,
+		Long:  WalPruneLongDescription,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			folder, err := internal.ConfigureFolder()
+			tracelog.ErrorLogger.FatalOnError(err)
+			prune := func() error { return internal.HandleWalPrune(folder, walPruneDryRun, os.Stdout) }
+			if !walPruneDryRun {
+				err = internal.WithConfiguredStorageLock(folder, "wal-prune", breakStaleLock, prune)
+			} else {
+				err = prune()
+			}
+			tracelog.ErrorLogger.FatalOnError(err)
+		},
+	}
+	walPruneDryRun = false
+)
+
+func init() {
+	walPruneCmd.Flags().BoolVar(&walPruneDryRun, "dry-run", false, walPruneDryRunDescription)
+	addBreakStaleLockFlag(walPruneCmd.Flags())
+	Cmd.AddCommand(walPruneCmd)
+}
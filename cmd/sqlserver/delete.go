@@ -9,6 +9,7 @@ import (
 )
 
 var confirmed = false
+var breakStaleLock = false
 
 // deleteCmd represents the delete command
 var deleteCmd = &cobra.Command{
@@ -42,7 +43,7 @@ var deleteEverythingCmd = &cobra.Command{
 func runDeleteEverything(cmd *cobra.Command, args []string) {
 	folder, err := internal.ConfigureFolder()
 	tracelog.ErrorLogger.FatalOnError(err)
-	internal.DeleteEverything(folder, confirmed, args)
+	internal.DeleteEverything(folder, confirmed, breakStaleLock, args)
 }
 
 func runDeleteBefore(cmd *cobra.Command, args []string) {
@@ -51,7 +52,7 @@ func runDeleteBefore(cmd *cobra.Command, args []string) {
 	isFullBackup := func(object storage.Object) bool {
 		return IsFullBackup(folder, object)
 	}
-	internal.HandleDeleteBefore(folder, args, confirmed, isFullBackup, GetLessFunc(folder))
+	internal.HandleDeleteBefore(folder, args, confirmed, breakStaleLock, isFullBackup, GetLessFunc(folder))
 }
 
 func runDeleteRetain(cmd *cobra.Command, args []string) {
@@ -60,13 +61,15 @@ func runDeleteRetain(cmd *cobra.Command, args []string) {
 	isFullBackup := func(object storage.Object) bool {
 		return IsFullBackup(folder, object)
 	}
-	internal.HandleDeleteRetain(folder, args, confirmed, isFullBackup, GetLessFunc(folder))
+	internal.HandleDeleteRetain(folder, args, confirmed, breakStaleLock, isFullBackup, GetLessFunc(folder))
 }
 
 func init() {
 	Cmd.AddCommand(deleteCmd)
 	deleteCmd.AddCommand(deleteBeforeCmd, deleteRetainCmd, deleteEverythingCmd)
 	deleteCmd.PersistentFlags().BoolVar(&confirmed, internal.ConfirmFlag, false, "Confirms backup deletion")
+	deleteCmd.PersistentFlags().BoolVar(&breakStaleLock, internal.BreakStaleLockFlag, false,
+		internal.BreakStaleLockDescription)
 }
 
 func IsFullBackup(folder storage.Folder, object storage.Object) bool {
@@ -23,6 +23,8 @@ const (
 
 var UnwrapAll map[string]bool = nil
 
+var pgControlTarRegexp = regexp.MustCompile(`^.*?pg_control\.tar(\..+$|$)`)
+
 var UtilityFilePaths = map[string]bool{
 	PgControlPath:         true,
 	BackupLabelFilename:   true,
@@ -72,10 +74,9 @@ func (backup *Backup) CheckExistence() (bool, error) {
 }
 
 func (backup *Backup) GetTarNames() ([]string, error) {
-	tarPartitionFolder := backup.getTarPartitionFolder()
-	objects, _, err := tarPartitionFolder.ListFolder()
+	objects, err := backup.getTarObjects()
 	if err != nil {
-		return nil, errors.Wrapf(err, "unable to list backup '%s' for deletion", backup.Name)
+		return nil, err
 	}
 	result := make([]string, len(objects))
 	for id, object := range objects {
@@ -84,6 +85,14 @@ func (backup *Backup) GetTarNames() ([]string, error) {
 	return result, nil
 }
 
+func (backup *Backup) getTarObjects() ([]storage.Object, error) {
+	objects, _, err := backup.getTarPartitionFolder().ListFolder()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to list backup '%s' for deletion", backup.Name)
+	}
+	return objects, nil
+}
+
 func (backup *Backup) GetSentinel() (BackupSentinelDto, error) {
 	if backup.SentinelDto != nil {
 		return *backup.SentinelDto, nil
@@ -113,7 +122,7 @@ func (backup *Backup) fetchSentinelData() ([]byte, error) {
 	if err != nil {
 		return sentinelDtoData, errors.Wrap(err, "failed to fetch sentinel")
 	}
-	return sentinelDtoData, nil
+	return decompressMetadata(sentinelDtoData)
 }
 
 func (backup *Backup) fetchMeta() (ExtendedMetadataDto, error) {
@@ -127,6 +136,10 @@ func (backup *Backup) fetchMeta() (ExtendedMetadataDto, error) {
 	if err != nil {
 		return extendedMetadataDto, errors.Wrap(err, "failed to fetch metadata")
 	}
+	extendedMetadataDtoData, err = decompressMetadata(extendedMetadataDtoData)
+	if err != nil {
+		return extendedMetadataDto, err
+	}
 
 	err = json.Unmarshal(extendedMetadataDtoData, &extendedMetadataDto)
 	return extendedMetadataDto, errors.Wrap(err, "failed to unmarshal metadata")
@@ -208,7 +221,7 @@ func (backup *Backup) unwrapOld(
 	dbDataDirectory string, sentinelDto BackupSentinelDto, filesToUnwrap map[string]bool, createIncrementalFiles bool,
 ) error {
 	tarInterpreter := NewFileTarInterpreter(dbDataDirectory, sentinelDto, filesToUnwrap, createIncrementalFiles)
-	tarsToExtract, pgControlKey, err := backup.getTarsToExtract(sentinelDto, filesToUnwrap, false)
+	tarsToExtract, pgControlTar, err := backup.getTarsToExtract(sentinelDto, filesToUnwrap, false)
 	if err != nil {
 		return err
 	}
@@ -216,7 +229,7 @@ func (backup *Backup) unwrapOld(
 	// Check name for backwards compatibility. Will check for `pg_control` if WALG version of backup.
 	needPgControl := IsPgControlRequired(backup, sentinelDto)
 
-	if pgControlKey == "" && needPgControl {
+	if pgControlTar == nil && needPgControl {
 		return newPgControlNotFoundError()
 	}
 
@@ -226,7 +239,7 @@ func (backup *Backup) unwrapOld(
 	}
 
 	if needPgControl {
-		err = ExtractAll(tarInterpreter, []ReaderMaker{newStorageReaderMaker(backup.getTarPartitionFolder(), pgControlKey)})
+		err = ExtractAll(tarInterpreter, []ReaderMaker{pgControlTar})
 		if err != nil {
 			return errors.Wrap(err, "failed to extract pg_control")
 		}
@@ -258,26 +271,26 @@ func isDirectoryEmpty(directoryPath string) (bool, error) {
 }
 
 // TODO : init tests
-func (backup *Backup) getTarsToExtract(sentinelDto BackupSentinelDto, filesToUnwrap map[string]bool, skipRedundantTars bool) (tarsToExtract []ReaderMaker, pgControlKey string, err error) {
-	tarNames, err := backup.GetTarNames()
+func (backup *Backup) getTarsToExtract(sentinelDto BackupSentinelDto, filesToUnwrap map[string]bool, skipRedundantTars bool) (tarsToExtract []ReaderMaker, pgControlTar ReaderMaker, err error) {
+	tarObjects, err := backup.getTarObjects()
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	tracelog.DebugLogger.Printf("Tars to extract: '%+v'\n", tarNames)
-	tarsToExtract = make([]ReaderMaker, 0, len(tarNames))
+	tracelog.DebugLogger.Printf("Tars to extract: '%+v'\n", tarObjects)
+	tarsToExtract = make([]ReaderMaker, 0, len(tarObjects))
 
-	pgControlRe := regexp.MustCompile(`^.*?pg_control\.tar(\..+$|$)`)
-	for _, tarName := range tarNames {
+	for _, tarObject := range tarObjects {
+		tarName := tarObject.GetName()
 		// Separate the pg_control tarName from the others to
 		// extract it at the end, as to prevent server startup
 		// with incomplete backup restoration.  But only if it
 		// exists: it won't in the case of WAL-E backup
 		// backwards compatibility.
-		if pgControlRe.MatchString(tarName) {
-			if pgControlKey != "" {
+		if pgControlTarRegexp.MatchString(tarName) {
+			if pgControlTar != nil {
 				panic("expect only one pg_control tar name match")
 			}
-			pgControlKey = tarName
+			pgControlTar = newListedStorageReaderMaker(backup.getTarPartitionFolder(), tarObject)
 			continue
 		}
 
@@ -285,7 +298,7 @@ func (backup *Backup) getTarsToExtract(sentinelDto BackupSentinelDto, filesToUnw
 			continue
 		}
 
-		tarToExtract := newStorageReaderMaker(backup.getTarPartitionFolder(), tarName)
+		tarToExtract := newListedStorageReaderMaker(backup.getTarPartitionFolder(), tarObject)
 		tarsToExtract = append(tarsToExtract, tarToExtract)
 	}
 	return
@@ -332,13 +345,23 @@ func getLastWalFilename(backup *Backup) (string, error) {
 		tracelog.InfoLogger.Print("No meta found.")
 		return "", err
 	}
-	prefixLenght := len(utility.BackupNamePrefix)
-	timelineID64, err := strconv.ParseUint(backup.Name[prefixLenght:prefixLenght+8], hexadecimal, sizeofInt32bits)
+	timelineID, err := getBackupTimeline(backup.Name)
 	if err != nil {
 		tracelog.InfoLogger.FatalError(err)
 		return "", err
 	}
-	timelineID := uint32(timelineID64)
 	endWalSegmentNo := newWalSegmentNo(meta.FinishLsn - 1)
 	return endWalSegmentNo.getFilename(timelineID), nil
 }
+
+func getBackupTimeline(backupName string) (uint32, error) {
+	prefixLength := len(utility.BackupNamePrefix)
+	if len(backupName) < prefixLength+8 {
+		return 0, errors.Errorf("failed to parse timeline of backup '%s'", backupName)
+	}
+	timelineID64, err := strconv.ParseUint(backupName[prefixLength:prefixLength+8], hexadecimal, sizeofInt32bits)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(timelineID64), nil
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/utility"
+)
+
+// BackupFilesDiff lists files which differ between two backups
+type BackupFilesDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// DiffBackupFileLists compares files of newer backup with files of older one.
+// A file present in both is changed when its modification time differs.
+func DiffBackupFileLists(older, newer BackupFileList) BackupFilesDiff {
+	diff := BackupFilesDiff{Added: make([]string, 0), Removed: make([]string, 0), Changed: make([]string, 0)}
+	for name, newerDescription := range newer {
+		olderDescription, ok := older[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+		} else if !olderDescription.MTime.Equal(newerDescription.MTime) {
+			diff.Changed = append(diff.Changed, name)
+		}
+	}
+	for name := range older {
+		if _, ok := newer[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// HandleBackupDiff prints files added (+), removed (-) and changed (~) in newer backup
+// compared with older one. Only sentinels are read.
+func HandleBackupDiff(folder storage.Folder, olderName, newerName string, output io.Writer) error {
+	fileLists := make([]BackupFileList, 0, 2)
+	for _, backupName := range []string{olderName, newerName} {
+		backup, err := GetBackupByName(backupName, utility.BaseBackupPath, folder)
+		if err != nil {
+			return err
+		}
+		sentinel, err := backup.GetSentinel()
+		if err != nil {
+			return err
+		}
+		fileLists = append(fileLists, sentinel.Files)
+	}
+	diff := DiffBackupFileLists(fileLists[0], fileLists[1])
+	for _, group := range []struct {
+		mark  string
+		names []string
+	}{{"+", diff.Added}, {"-", diff.Removed}, {"~", diff.Changed}} {
+		for _, name := range group.names {
+			if _, err := fmt.Fprintf(output, "%s %s\n", group.mark, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
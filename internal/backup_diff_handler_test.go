@@ -0,0 +1,56 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+var diffBaseTime = time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+
+func makeDiffFileList(mTimes map[string]time.Duration) internal.BackupFileList {
+	files := make(internal.BackupFileList)
+	for name, offset := range mTimes {
+		files[name] = *internal.NewBackupFileDescription(false, false, diffBaseTime.Add(offset))
+	}
+	return files
+}
+
+func TestDiffBackupFileLists(t *testing.T) {
+	older := makeDiffFileList(map[string]time.Duration{
+		"base/1/1000": 0, "base/1/1001": 0, "base/1/1002": 0, "global/1260": 0,
+	})
+	newer := makeDiffFileList(map[string]time.Duration{
+		"base/1/1000": 0, "base/1/1002": time.Hour, "global/1260": 0, "base/1/2000": time.Hour, "base/1/1003": 0,
+	})
+
+	diff := internal.DiffBackupFileLists(older, newer)
+	assert.Equal(t, []string{"base/1/1003", "base/1/2000"}, diff.Added)
+	assert.Equal(t, []string{"base/1/1001"}, diff.Removed)
+	assert.Equal(t, []string{"base/1/1002"}, diff.Changed)
+}
+
+func TestHandleBackupDiff(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	baseBackupFolder := folder.GetSubFolder(utility.BaseBackupPath)
+	fileLists := map[string]internal.BackupFileList{
+		"base_000000010000000000000002": makeDiffFileList(map[string]time.Duration{"a": 0, "b": 0}),
+		"base_000000010000000000000004": makeDiffFileList(map[string]time.Duration{"a": time.Minute, "c": 0}),
+	}
+	for backupName, files := range fileLists {
+		sentinelBytes, err := json.Marshal(internal.BackupSentinelDto{Files: files})
+		assert.NoError(t, err)
+		assert.NoError(t, baseBackupFolder.PutObject(backupName+utility.SentinelSuffix, bytes.NewReader(sentinelBytes)))
+	}
+
+	var output bytes.Buffer
+	err := internal.HandleBackupDiff(folder, "base_000000010000000000000002", "base_000000010000000000000004", &output)
+	assert.NoError(t, err)
+	assert.Equal(t, "+ c\n- b\n~ a\n", output.String())
+}
@@ -0,0 +1,192 @@
+package internal
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/utility"
+)
+
+// BackupExportIndexName is the first entry of an export archive, it lists all exported objects
+const BackupExportIndexName = "walg_export_index.json"
+
+type BackupExportIndex struct {
+	BackupName string `json:"backup_name"`
+	// BaseBackupNames are backups which delta BackupName is based on, from the closest one to the full backup
+	BaseBackupNames []string                  `json:"base_backup_names,omitempty"`
+	Objects         []BackupExportIndexObject `json:"objects"`
+}
+
+type BackupExportIndexObject struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// ExportBackup writes sentinel, metadata and tars of backup with their storage names to a single tar stream
+// headed by BackupExportIndexName, so the backup can be moved as one file and restored by ImportBackup.
+// Delta backups are exported with all backups they are based on.
+// WAL segments are not exported, so the backup can't be restored to a consistent state without WAL archive.
+func ExportBackup(rootFolder storage.Folder, backupName string, output io.Writer) error {
+	backup, err := GetBackupByName(backupName, utility.BaseBackupPath, rootFolder)
+	if err != nil {
+		return err
+	}
+	index := BackupExportIndex{BackupName: backup.Name, Objects: make([]BackupExportIndexObject, 0)}
+	// only sentinels lie at the top level of base backups folder, so the listing is small
+	sentinels, _, err := backup.BaseBackupFolder.ListFolder()
+	if err != nil {
+		return err
+	}
+	for {
+		objects, err := getBackupExportObjects(backup, sentinels)
+		if err != nil {
+			return err
+		}
+		index.Objects = append(index.Objects, objects...)
+		sentinel, err := backup.GetSentinel()
+		if err != nil {
+			return err
+		}
+		if !sentinel.IsIncremental() {
+			break
+		}
+		backup = NewBackup(backup.BaseBackupFolder, *sentinel.IncrementFrom)
+		index.BaseBackupNames = append(index.BaseBackupNames, backup.Name)
+	}
+	sort.Slice(index.Objects, func(i, j int) bool { return index.Objects[i].Name < index.Objects[j].Name })
+
+	tarWriter := tar.NewWriter(output)
+	indexBytes, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	err = tarWriter.WriteHeader(&tar.Header{Name: BackupExportIndexName, Mode: 0640, Size: int64(len(indexBytes))})
+	if err != nil {
+		return err
+	}
+	if _, err = tarWriter.Write(indexBytes); err != nil {
+		return err
+	}
+	for _, object := range index.Objects {
+		err = exportObject(rootFolder, object, tarWriter)
+		if err != nil {
+			return errors.Wrapf(err, "failed to export '%s'", object.Name)
+		}
+	}
+	tracelog.InfoLogger.Printf("Exported %d objects of backup '%s'\n", len(index.Objects), index.BackupName)
+	return tarWriter.Close()
+}
+
+// getBackupExportObjects returns the sentinel and all objects of backup folder
+func getBackupExportObjects(backup *Backup, sentinels []storage.Object) ([]BackupExportIndexObject, error) {
+	result := make([]BackupExportIndexObject, 0)
+	for _, object := range sentinels {
+		if object.GetName() == backup.Name+utility.SentinelSuffix {
+			result = append(result, BackupExportIndexObject{
+				path.Join(utility.BaseBackupPath, object.GetName()), object.GetSize()})
+		}
+	}
+	if len(result) == 0 {
+		return nil, NewBackupNonExistenceError(backup.Name)
+	}
+	objects, err := storage.ListFolderRecursively(backup.BaseBackupFolder.GetSubFolder(backup.Name))
+	if err != nil {
+		return nil, err
+	}
+	for _, object := range objects {
+		result = append(result, BackupExportIndexObject{
+			path.Join(utility.BaseBackupPath, backup.Name, object.GetName()), object.GetSize()})
+	}
+	return result, nil
+}
+
+// validateBackupExportIndex checks that the index puts objects only to folders and sentinels of its backups,
+// so a crafted or corrupted archive can't overwrite other objects of storage
+func validateBackupExportIndex(index BackupExportIndex) error {
+	allowed := make([]string, 0, len(index.BaseBackupNames)+1)
+	for _, backupName := range append([]string{index.BackupName}, index.BaseBackupNames...) {
+		if backupName == "" || strings.Contains(backupName, "/") || strings.Contains(backupName, "..") {
+			return errors.Errorf("invalid backup name '%s' in export index", backupName)
+		}
+		allowed = append(allowed, path.Join(utility.BaseBackupPath, backupName))
+	}
+	for _, object := range index.Objects {
+		if !isBackupExportObjectAllowed(object.Name, allowed) {
+			return errors.Errorf("export index entry '%s' is not an object of backup '%s'", object.Name, index.BackupName)
+		}
+	}
+	return nil
+}
+
+func isBackupExportObjectAllowed(name string, backupPaths []string) bool {
+	if path.IsAbs(name) || strings.Contains(name, "..") || path.Clean(name) != name {
+		return false
+	}
+	for _, backupPath := range backupPaths {
+		if name == backupPath+utility.SentinelSuffix || strings.HasPrefix(name, backupPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func exportObject(rootFolder storage.Folder, object BackupExportIndexObject, tarWriter *tar.Writer) error {
+	reader, err := rootFolder.ReadObject(object.Name)
+	if err != nil {
+		return err
+	}
+	defer utility.LoggedClose(reader, "")
+	err = tarWriter.WriteHeader(&tar.Header{Name: object.Name, Mode: 0640, Size: object.Size})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tarWriter, reader)
+	return err
+}
+
+// ImportBackup puts objects of an archive written by ExportBackup to rootFolder under their original names
+func ImportBackup(rootFolder storage.Folder, input io.Reader) (BackupExportIndex, error) {
+	tarReader := tar.NewReader(input)
+	header, err := tarReader.Next()
+	if err != nil {
+		return BackupExportIndex{}, errors.Wrap(err, "failed to read export index")
+	}
+	if header.Name != BackupExportIndexName {
+		return BackupExportIndex{}, errors.Errorf("archive doesn't start with %s, is it made by backup-export?", BackupExportIndexName)
+	}
+	indexBytes, err := ioutil.ReadAll(tarReader)
+	if err != nil {
+		return BackupExportIndex{}, err
+	}
+	var index BackupExportIndex
+	if err = json.Unmarshal(indexBytes, &index); err != nil {
+		return BackupExportIndex{}, errors.Wrap(err, "failed to parse export index")
+	}
+	if err = validateBackupExportIndex(index); err != nil {
+		return BackupExportIndex{}, err
+	}
+	for _, object := range index.Objects {
+		header, err = tarReader.Next()
+		if err != nil {
+			return index, errors.Wrapf(err, "archive ends before '%s'", object.Name)
+		}
+		if header.Name != object.Name || header.Size != object.Size {
+			return index, errors.Errorf("archive entry '%s' of %d bytes doesn't match index entry '%s' of %d bytes",
+				header.Name, header.Size, object.Name, object.Size)
+		}
+		err = rootFolder.PutObject(object.Name, tarReader)
+		if err != nil {
+			return index, errors.Wrapf(err, "failed to import '%s'", object.Name)
+		}
+	}
+	tracelog.InfoLogger.Printf("Imported %d objects of backup '%s'\n", len(index.Objects), index.BackupName)
+	return index, nil
+}
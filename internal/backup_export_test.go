@@ -0,0 +1,167 @@
+package internal_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+func TestExportImportBackup_RoundTrip(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	backupName := "base_000000010000000000000002"
+	exported := map[string]string{
+		"basebackups_005/" + backupName + utility.SentinelSuffix:               "{}",
+		"basebackups_005/" + backupName + "/metadata.json":                     `{"hostname":"db1"}`,
+		"basebackups_005/" + backupName + "/tar_partitions/part_001.tar.lz4":   strings.Repeat("data", 1000),
+		"basebackups_005/" + backupName + "/tar_partitions/pg_control.tar.lz4": "control",
+	}
+	for name, content := range exported {
+		require.NoError(t, source.PutObject(name, strings.NewReader(content)))
+	}
+	otherBackup := "basebackups_005/" + backupName + "_D_000000010000000000000001" + utility.SentinelSuffix
+	require.NoError(t, source.PutObject(otherBackup, strings.NewReader("{}")))
+	require.NoError(t, source.PutObject("wal_005/000000010000000000000002.lz4", strings.NewReader("wal")))
+
+	archive := new(bytes.Buffer)
+	require.NoError(t, internal.ExportBackup(source, backupName, archive))
+
+	destination := testtools.MakeDefaultInMemoryStorageFolder()
+	index, err := internal.ImportBackup(destination, archive)
+	require.NoError(t, err)
+	assert.Equal(t, backupName, index.BackupName)
+	assert.Len(t, index.Objects, len(exported))
+
+	names := getFolderObjectNames(t, destination)
+	assert.Len(t, names, len(exported))
+	for name, content := range exported {
+		reader, err := destination.ReadObject(name)
+		require.NoError(t, err)
+		imported, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(imported), name)
+	}
+}
+
+// unlistableFolder fails to list its own top level, its sub folders are listed as usual
+type unlistableFolder struct {
+	storage.Folder
+}
+
+func (folder unlistableFolder) ListFolder() ([]storage.Object, []storage.Folder, error) {
+	return nil, nil, errors.New("listing the whole storage")
+}
+
+func TestExportBackup_ListsOnlyBackupObjects(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	backupName := "base_000000010000000000000002"
+	require.NoError(t, source.PutObject("basebackups_005/"+backupName+utility.SentinelSuffix, strings.NewReader("{}")))
+	require.NoError(t, source.PutObject("basebackups_005/"+backupName+"/metadata.json", strings.NewReader("{}")))
+
+	archive := new(bytes.Buffer)
+	require.NoError(t, internal.ExportBackup(unlistableFolder{source}, backupName, archive))
+
+	index, err := internal.ImportBackup(testtools.MakeDefaultInMemoryStorageFolder(), archive)
+	require.NoError(t, err)
+	assert.Len(t, index.Objects, 2)
+}
+
+func TestExportBackup_DeltaIsExportedWithBaseBackups(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	fullName := "base_000000010000000000000002"
+	deltaName := "base_000000010000000000000004_D_000000010000000000000002"
+	lsn, count := uint64(1), 1
+	deltaSentinel, err := json.Marshal(internal.BackupSentinelDto{
+		IncrementFromLSN: &lsn, IncrementFrom: &fullName, IncrementFullName: &fullName, IncrementCount: &count})
+	require.NoError(t, err)
+	require.NoError(t, source.PutObject("basebackups_005/"+fullName+utility.SentinelSuffix, strings.NewReader("{}")))
+	require.NoError(t, source.PutObject("basebackups_005/"+fullName+"/metadata.json", strings.NewReader("{}")))
+	require.NoError(t, source.PutObject("basebackups_005/"+deltaName+utility.SentinelSuffix, bytes.NewReader(deltaSentinel)))
+	require.NoError(t, source.PutObject("basebackups_005/"+deltaName+"/metadata.json", strings.NewReader("{}")))
+
+	archive := new(bytes.Buffer)
+	require.NoError(t, internal.ExportBackup(source, deltaName, archive))
+
+	destination := testtools.MakeDefaultInMemoryStorageFolder()
+	index, err := internal.ImportBackup(destination, archive)
+	require.NoError(t, err)
+	assert.Equal(t, []string{fullName}, index.BaseBackupNames)
+	assert.ElementsMatch(t, getFolderObjectNames(t, source), getFolderObjectNames(t, destination))
+}
+
+func TestExportBackup_DeltaWithMissingBaseBackupFails(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	fullName := "base_000000010000000000000002"
+	deltaName := "base_000000010000000000000004_D_000000010000000000000002"
+	lsn, count := uint64(1), 1
+	deltaSentinel, err := json.Marshal(internal.BackupSentinelDto{
+		IncrementFromLSN: &lsn, IncrementFrom: &fullName, IncrementFullName: &fullName, IncrementCount: &count})
+	require.NoError(t, err)
+	require.NoError(t, source.PutObject("basebackups_005/"+deltaName+utility.SentinelSuffix, bytes.NewReader(deltaSentinel)))
+
+	assert.Error(t, internal.ExportBackup(source, deltaName, new(bytes.Buffer)))
+}
+
+func writeExportArchive(t *testing.T, index internal.BackupExportIndex) *bytes.Buffer {
+	archive := new(bytes.Buffer)
+	tarWriter := tar.NewWriter(archive)
+	indexBytes, err := json.Marshal(index)
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: internal.BackupExportIndexName, Mode: 0640,
+		Size: int64(len(indexBytes))}))
+	_, err = tarWriter.Write(indexBytes)
+	require.NoError(t, err)
+	for _, object := range index.Objects {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: object.Name, Mode: 0640, Size: object.Size}))
+		_, err = tarWriter.Write(make([]byte, object.Size))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+	return archive
+}
+
+func TestImportBackup_RejectsObjectsOutsideOfBackup(t *testing.T) {
+	backupName := "base_000000010000000000000002"
+	for _, name := range []string{
+		"wal_005/000000010000000000000002.lz4",
+		"basebackups_005/base_000000010000000000000004" + utility.SentinelSuffix,
+		"basebackups_005/" + backupName + "/../../wal_005/000000010000000000000002.lz4",
+		"/basebackups_005/" + backupName + "/metadata.json",
+	} {
+		destination := testtools.MakeDefaultInMemoryStorageFolder()
+		archive := writeExportArchive(t, internal.BackupExportIndex{BackupName: backupName,
+			Objects: []internal.BackupExportIndexObject{
+				{Name: "basebackups_005/" + backupName + "/metadata.json", Size: 1},
+				{Name: name, Size: 1},
+			}})
+
+		_, err := internal.ImportBackup(destination, archive)
+
+		assert.Error(t, err, name)
+		assert.Empty(t, getFolderObjectNames(t, destination), name)
+	}
+}
+
+func TestImportBackup_RejectsInvalidBackupName(t *testing.T) {
+	archive := writeExportArchive(t, internal.BackupExportIndex{BackupName: "..",
+		Objects: []internal.BackupExportIndexObject{{Name: "basebackups_005/../wal_005/x", Size: 1}}})
+
+	_, err := internal.ImportBackup(testtools.MakeDefaultInMemoryStorageFolder(), archive)
+
+	assert.Error(t, err)
+}
+
+func TestImportBackup_RejectsForeignArchive(t *testing.T) {
+	_, err := internal.ImportBackup(testtools.MakeDefaultInMemoryStorageFolder(), strings.NewReader("not a tar"))
+	assert.Error(t, err)
+}
@@ -3,6 +3,7 @@ package internal_test
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wal-g/wal-g/internal"
@@ -18,6 +19,21 @@ func TestGetBackupByName_Latest(t *testing.T) {
 	assert.Equal(t, "base_000", backup.Name)
 }
 
+func TestGetBackupByName_LatestIsNewestByTime(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	baseBackupFolder := folder.GetSubFolder(utility.BaseBackupPath)
+	// the newest backup sorts before the others by name, so LATEST can't be found by name
+	for _, backupName := range []string{"base_000000010000000000000005", "base_000000020000000000000007",
+		"base_000000010000000000000009", "base_000000010000000000000003"} {
+		assert.NoError(t, baseBackupFolder.PutObject(backupName+utility.SentinelSuffix, &bytes.Buffer{}))
+		time.Sleep(time.Millisecond)
+	}
+
+	backup, err := internal.GetBackupByName(internal.LatestString, utility.BaseBackupPath, folder)
+	assert.NoError(t, err)
+	assert.Equal(t, "base_000000010000000000000003", backup.Name)
+}
+
 func TestGetBackupByName_LatestNoBackups(t *testing.T) {
 	folder := testtools.MakeDefaultInMemoryStorageFolder()
 	folder.PutObject("folder123/nop", &bytes.Buffer{})
@@ -197,6 +197,10 @@ func getMetadataUploadObject(backupName string, meta ExtendedMetadataDto) (Uploa
 	if err != nil {
 		return UploadObject{}, err
 	}
+	dtoBody, err = compressMetadataIfConfigured(dtoBody)
+	if err != nil {
+		return UploadObject{}, err
+	}
 	return UploadObject{metaFilePath, bytes.NewReader(dtoBody)}, nil
 }
 
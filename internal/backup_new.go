@@ -74,7 +74,7 @@ func (backup *Backup) unwrapNew(
 	}
 
 	tarInterpreter := NewFileTarInterpreter(dbDataDirectory, sentinelDto, filesToUnwrap, createIncrementalFiles)
-	tarsToExtract, pgControlKey, err := backup.getTarsToExtract(sentinelDto, filesToUnwrap, skipRedundantTars)
+	tarsToExtract, pgControlTar, err := backup.getTarsToExtract(sentinelDto, filesToUnwrap, skipRedundantTars)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +82,7 @@ func (backup *Backup) unwrapNew(
 	// Check name for backwards compatibility. Will check for `pg_control` if WALG version of backup.
 	needPgControl := IsPgControlRequired(backup, sentinelDto)
 
-	if pgControlKey == "" && needPgControl {
+	if pgControlTar == nil && needPgControl {
 		return nil, newPgControlNotFoundError()
 	}
 
@@ -97,7 +97,7 @@ func (backup *Backup) unwrapNew(
 	}
 
 	if needPgControl {
-		err = ExtractAll(tarInterpreter, []ReaderMaker{newStorageReaderMaker(backup.getTarPartitionFolder(), pgControlKey)})
+		err = ExtractAll(tarInterpreter, []ReaderMaker{pgControlTar})
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to extract pg_control")
 		}
@@ -77,7 +77,7 @@ func createAndPushBackup(
 	incrementCount int,
 	verifyPageChecksums, storeAllCorruptBlocks bool,
 	tarBallComposerType TarBallComposerType,
-) {
+) error {
 	folder := uploader.UploadingFolder
 	uploader.UploadingFolder = folder.GetSubFolder(backupsFolder) // TODO: AB: this subfolder switch look ugly. I think typed storage folders could be better (i.e. interface BasebackupStorageFolder, WalStorageFolder etc)
 
@@ -92,7 +92,9 @@ func createAndPushBackup(
 
 	// Connect to postgres and start/finish a nonexclusive backup.
 	conn, err := Connect()
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	backupName, backupStartLSN, pgVersion, dataDir, systemIdentifier, err := bundle.StartBackup(conn,
 		utility.CeilTimeUpToMicroseconds(time.Now()).String())
 	meta.DataDir = dataDir
@@ -100,14 +102,16 @@ func createAndPushBackup(
 		warning := fmt.Sprintf("Data directory '%s' is not equal to backup-push argument '%s'", dataDir, archiveDirectory)
 		tracelog.WarningLogger.Println(warning)
 	}
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 
 	if len(previousBackupName) > 0 && previousBackupSentinelDto.BackupStartLSN != nil {
 		if *previousBackupSentinelDto.BackupFinishLSN > backupStartLSN {
-			tracelog.ErrorLogger.FatalOnError(newBackupFromFuture(previousBackupName))
+			return newBackupFromFuture(previousBackupName)
 		}
 		if previousBackupSentinelDto.SystemIdentifier != nil && systemIdentifier != nil && *systemIdentifier != *previousBackupSentinelDto.SystemIdentifier {
-			tracelog.ErrorLogger.FatalOnError(newBackupFromOtherBD())
+			return newBackupFromOtherBD()
 		}
 		if uploader.getUseWalDelta() {
 			err = bundle.DownloadDeltaMap(folder.GetSubFolder(utility.WalPath), backupStartLSN)
@@ -122,24 +126,40 @@ func createAndPushBackup(
 
 	// Start a new tar bundle, walk the archiveDirectory and upload everything there.
 	err = bundle.StartQueue(NewStorageTarBallMaker(backupName, uploader.Uploader))
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	tarBallComposerMaker, err := NewTarBallComposerMaker(tarBallComposerType, conn,
 		NewTarBallFilePackerOptions(verifyPageChecksums, storeAllCorruptBlocks))
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	err = bundle.SetupComposer(tarBallComposerMaker)
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	tracelog.InfoLogger.Println("Walking ...")
 	err = filepath.Walk(archiveDirectory, bundle.HandleWalkedFSObject)
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	tarFileSets, err := bundle.PackTarballs()
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	err = bundle.FinishQueue()
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	err = bundle.UploadPgControl(uploader.Compressor.FileExtension())
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	// Stops backup and write/upload postgres `backup_label` and `tablespace_map` Files
 	labelFilesTarBallName, labelFilesList, finishLsn, err := bundle.uploadLabelFiles(conn)
-	tracelog.ErrorLogger.FatalOnError(err)
+	if err != nil {
+		return err
+	}
 	uncompressedSize := atomic.LoadInt64(bundle.TarBallQueue.AllTarballsSize)
 	compressedSize := atomic.LoadInt64(uploader.tarSize)
 	tarFileSets[labelFilesTarBallName] = append(tarFileSets[labelFilesTarBallName], labelFilesList...)
@@ -148,10 +168,10 @@ func createAndPushBackup(
 	// Wait for all uploads to finish.
 	uploader.finish()
 	if uploader.Failed.Load().(bool) {
-		tracelog.ErrorLogger.Fatalf("Uploading failed during '%s' backup.\n", backupName)
+		return errors.Errorf("uploading failed during '%s' backup", backupName)
 	}
 	if timelineChanged {
-		tracelog.ErrorLogger.Fatalf("Cannot finish backup because of changed timeline.")
+		return errors.New("cannot finish backup because of changed timeline")
 	}
 
 	var tablespaceSpec *TablespaceSpec
@@ -190,25 +210,35 @@ func createAndPushBackup(
 	err = uploadMetadata(uploader.Uploader, currentBackupSentinelDto, backupName, meta)
 	if err != nil {
 		tracelog.ErrorLogger.Printf("Failed to upload metadata file for backup: %s %v", backupName, err)
-		tracelog.ErrorLogger.FatalError(err)
+		return err
 	}
 	err = UploadSentinel(uploader.Uploader, currentBackupSentinelDto, backupName)
 	if err != nil {
 		tracelog.ErrorLogger.Printf("Failed to upload sentinel file for backup: %s", backupName)
-		tracelog.ErrorLogger.FatalError(err)
+		return err
 	}
 	// logging backup set name
 	tracelog.InfoLogger.Println("Wrote backup with name " + backupName)
-
+	return nil
 }
 
 // TODO : unit tests
 // HandleBackupPush is invoked to perform a wal-g backup-push
 func HandleBackupPush(uploader *WalUploader, archiveDirectory string, isPermanent, isFullBackup,
-	verifyPageChecksums, storeAllCorruptBlocks bool, tarBallComposerType TarBallComposerType) {
+	verifyPageChecksums, storeAllCorruptBlocks, breakStaleLock bool, tarBallComposerType TarBallComposerType) {
 	archiveDirectory = utility.ResolveSymlink(archiveDirectory)
 	maxDeltas, fromFull := getDeltaConfig()
 	checkPgVersionAndPgControl(archiveDirectory)
+	// errors are returned from under the lock, so that a failed backup releases it before exit
+	err := WithConfiguredStorageLock(uploader.UploadingFolder, "backup-push", breakStaleLock, func() error {
+		return pushBackup(uploader, archiveDirectory, maxDeltas, fromFull, isPermanent, isFullBackup,
+			verifyPageChecksums, storeAllCorruptBlocks, tarBallComposerType)
+	})
+	tracelog.ErrorLogger.FatalOnError(err)
+}
+
+func pushBackup(uploader *WalUploader, archiveDirectory string, maxDeltas int, fromFull, isPermanent, isFullBackup,
+	verifyPageChecksums, storeAllCorruptBlocks bool, tarBallComposerType TarBallComposerType) error {
 	var err error
 	var previousBackupSentinelDto BackupSentinelDto
 	var previousBackupName string
@@ -222,12 +252,14 @@ func HandleBackupPush(uploader *WalUploader, archiveDirectory string, isPermanen
 			if _, ok := err.(NoBackupsFoundError); ok {
 				tracelog.InfoLogger.Println("Couldn't find previous backup. Doing full backup.")
 			} else {
-				tracelog.ErrorLogger.FatalError(err)
+				return err
 			}
 		} else {
 			previousBackup := NewBackup(basebackupFolder, previousBackupName)
 			previousBackupSentinelDto, err = previousBackup.GetSentinel()
-			tracelog.ErrorLogger.FatalOnError(err)
+			if err != nil {
+				return err
+			}
 			if previousBackupSentinelDto.IncrementCount != nil {
 				incrementCount = *previousBackupSentinelDto.IncrementCount + 1
 			}
@@ -247,7 +279,9 @@ func HandleBackupPush(uploader *WalUploader, archiveDirectory string, isPermanen
 
 					previousBackup := NewBackup(basebackupFolder, previousBackupName)
 					previousBackupSentinelDto, err = previousBackup.GetSentinel()
-					tracelog.ErrorLogger.FatalOnError(err)
+					if err != nil {
+						return err
+					}
 				}
 				tracelog.InfoLogger.Printf("Delta backup from %v with LSN %x. \n", previousBackupName, *previousBackupSentinelDto.BackupStartLSN)
 			}
@@ -256,7 +290,7 @@ func HandleBackupPush(uploader *WalUploader, archiveDirectory string, isPermanen
 		tracelog.InfoLogger.Println("Doing full backup.")
 	}
 
-	createAndPushBackup(uploader, archiveDirectory, utility.BaseBackupPath, previousBackupName,
+	return createAndPushBackup(uploader, archiveDirectory, utility.BaseBackupPath, previousBackupName,
 		previousBackupSentinelDto, isPermanent, false, incrementCount, verifyPageChecksums,
 		storeAllCorruptBlocks, tarBallComposerType)
 }
@@ -280,6 +314,10 @@ func uploadMetadata(uploader *Uploader, sentinelDto *BackupSentinelDto, backupNa
 	if err != nil {
 		return newSentinelMarshallingError(metaFile, err)
 	}
+	dtoBody, err = compressMetadataIfConfigured(dtoBody)
+	if err != nil {
+		return err
+	}
 	return uploader.Upload(metaFile, bytes.NewReader(dtoBody))
 }
 
@@ -291,6 +329,10 @@ func UploadSentinel(uploader UploaderProvider, sentinelDto interface{}, backupNa
 	if err != nil {
 		return newSentinelMarshallingError(sentinelName, err)
 	}
+	dtoBody, err = compressMetadataIfConfigured(dtoBody)
+	if err != nil {
+		return err
+	}
 
 	return uploader.Upload(sentinelName, bytes.NewReader(dtoBody))
 }
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"io"
+
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/utility"
+)
+
+// BackupShowDto is the metadata of a single backup printed by backup-show
+type BackupShowDto struct {
+	BackupName string              `json:"backup_name"`
+	Sentinel   BackupSentinelDto   `json:"sentinel"`
+	Metadata   ExtendedMetadataDto `json:"metadata"`
+}
+
+// GetBackupShowDto reads sentinel and metadata of backup without touching its tars
+func GetBackupShowDto(folder storage.Folder, backupName string) (BackupShowDto, error) {
+	backup, err := GetBackupByName(backupName, utility.BaseBackupPath, folder)
+	if err != nil {
+		return BackupShowDto{}, err
+	}
+	sentinel, err := backup.GetSentinel()
+	if err != nil {
+		return BackupShowDto{}, err
+	}
+	meta, err := backup.fetchMeta()
+	if err != nil {
+		return BackupShowDto{}, err
+	}
+	return BackupShowDto{backup.Name, sentinel, meta}, nil
+}
+
+// HandleBackupShow prints metadata of the backup as JSON, backupName may be LATEST
+func HandleBackupShow(folder storage.Folder, backupName string, output io.Writer, pretty bool) error {
+	backupShowDto, err := GetBackupShowDto(folder, backupName)
+	if err != nil {
+		return err
+	}
+	return WriteAsJson(backupShowDto, output, pretty)
+}
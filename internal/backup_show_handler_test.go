@@ -0,0 +1,63 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+type readTrackingFolder struct {
+	storage.Folder
+	reads *[]string
+}
+
+func (folder *readTrackingFolder) GetSubFolder(subFolderRelativePath string) storage.Folder {
+	return &readTrackingFolder{folder.Folder.GetSubFolder(subFolderRelativePath), folder.reads}
+}
+
+func (folder *readTrackingFolder) ReadObject(objectRelativePath string) (io.ReadCloser, error) {
+	*folder.reads = append(*folder.reads, folder.GetPath()+objectRelativePath)
+	return folder.Folder.ReadObject(objectRelativePath)
+}
+
+func TestHandleBackupShow_ReadsNoTars(t *testing.T) {
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	baseBackupFolder := inner.GetSubFolder(utility.BaseBackupPath)
+	backupName := "base_000000010000000000000002"
+	assert.NoError(t, baseBackupFolder.PutObject(backupName+utility.SentinelSuffix,
+		strings.NewReader(`{"LSN": 16777216, "PgVersion": 120000}`)))
+	assert.NoError(t, baseBackupFolder.PutObject(backupName+"/"+utility.MetadataFileName,
+		strings.NewReader(`{"start_lsn": 16777216, "hostname": "db1"}`)))
+	assert.NoError(t, baseBackupFolder.PutObject(backupName+internal.TarPartitionFolderName+"part_1.tar.lz4",
+		strings.NewReader("data")))
+	reads := make([]string, 0)
+	folder := &readTrackingFolder{inner, &reads}
+
+	var output bytes.Buffer
+	assert.NoError(t, internal.HandleBackupShow(folder, internal.LatestString, &output, false))
+
+	var backupShowDto internal.BackupShowDto
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &backupShowDto))
+	assert.Equal(t, backupName, backupShowDto.BackupName)
+	assert.Equal(t, "db1", backupShowDto.Metadata.Hostname)
+	assert.Equal(t, 120000, backupShowDto.Sentinel.PgVersion)
+	for _, read := range reads {
+		assert.NotContains(t, read, internal.TarPartitionFolderName)
+	}
+}
+
+func TestHandleBackupShow_UnknownBackup(t *testing.T) {
+	folder := testtools.CreateMockStorageFolder()
+	var output bytes.Buffer
+	err := internal.HandleBackupShow(folder, "base_321", &output, false)
+	assert.IsType(t, internal.NewBackupNonExistenceError(""), err)
+	assert.Empty(t, output.String())
+}
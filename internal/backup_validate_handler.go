@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/utility"
+)
+
+type MissingBackupTarsError struct {
+	error
+}
+
+func newMissingBackupTarsError(backupName string, missingCount int) MissingBackupTarsError {
+	return MissingBackupTarsError{errors.Errorf("%d tars of backup '%s' are missing", missingCount, backupName)}
+}
+
+func (err MissingBackupTarsError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// FindMissingBackupTars returns sorted names of tars referenced by the backup sentinel
+// which are absent in storage, including the pg_control tar for backups which need it.
+// Sentinels of old backups do not reference tars, nothing is checked for them.
+func FindMissingBackupTars(backup *Backup) ([]string, error) {
+	sentinel, err := backup.GetSentinel()
+	if err != nil {
+		return nil, err
+	}
+	if len(sentinel.TarFileSets) == 0 {
+		tracelog.WarningLogger.Printf("Sentinel of backup '%s' does not reference tars, nothing to validate\n", backup.Name)
+		return []string{}, nil
+	}
+	tarNames, err := backup.GetTarNames()
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(tarNames))
+	hasPgControl := false
+	for _, tarName := range tarNames {
+		existing[tarName] = true
+		hasPgControl = hasPgControl || pgControlTarRegexp.MatchString(tarName)
+	}
+	missing := make([]string, 0)
+	for tarName := range sentinel.TarFileSets {
+		if !existing[tarName] {
+			missing = append(missing, tarName)
+		}
+	}
+	sort.Strings(missing)
+	if !hasPgControl && IsPgControlRequired(backup, sentinel) {
+		missing = append(missing, getPgControlTarName(sentinel))
+	}
+	return missing, nil
+}
+
+// getPgControlTarName guesses the name of pg_control tar by the compression of tars referenced by sentinel
+func getPgControlTarName(sentinel BackupSentinelDto) string {
+	for tarName := range sentinel.TarFileSets {
+		if index := strings.Index(tarName, ".tar"); index != -1 {
+			return "pg_control" + tarName[index:]
+		}
+	}
+	return "pg_control.tar"
+}
+
+// HandleBackupValidate prints tars referenced by the backup sentinel but missing in storage
+// and fails if there are any, backupName may be LATEST
+func HandleBackupValidate(folder storage.Folder, backupName string, output io.Writer) error {
+	backup, err := GetBackupByName(backupName, utility.BaseBackupPath, folder)
+	if err != nil {
+		return err
+	}
+	missing, err := FindMissingBackupTars(backup)
+	if err != nil {
+		return err
+	}
+	for _, tarName := range missing {
+		if _, err := fmt.Fprintln(output, backup.Name+TarPartitionFolderName+tarName); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return newMissingBackupTarsError(backup.Name, len(missing))
+	}
+	tracelog.InfoLogger.Printf("All tars of backup '%s' are present\n", backup.Name)
+	return nil
+}
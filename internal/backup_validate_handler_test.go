@@ -0,0 +1,69 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+const validatedBackupName = "base_000000010000000000000002"
+
+func putBackupWithTars(t *testing.T, folder storage.Folder, referenced, uploaded []string) {
+	baseBackupFolder := folder.GetSubFolder(utility.BaseBackupPath)
+	tarFileSets := make(internal.TarFileSets)
+	for _, tarName := range referenced {
+		tarFileSets[tarName] = []string{"base/1/" + tarName}
+	}
+	sentinelBytes, err := json.Marshal(internal.BackupSentinelDto{TarFileSets: tarFileSets})
+	require.NoError(t, err)
+	require.NoError(t, baseBackupFolder.PutObject(validatedBackupName+utility.SentinelSuffix, bytes.NewReader(sentinelBytes)))
+	for _, tarName := range uploaded {
+		tarPath := validatedBackupName + internal.TarPartitionFolderName + tarName
+		require.NoError(t, baseBackupFolder.PutObject(tarPath, bytes.NewBufferString(tarName)))
+	}
+}
+
+func TestHandleBackupValidate_ReportsMissingTars(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	putBackupWithTars(t, folder,
+		[]string{"part_001.tar.lz4", "part_002.tar.lz4", "part_003.tar.lz4"},
+		[]string{"part_002.tar.lz4", "pg_control.tar.lz4"})
+
+	output := new(bytes.Buffer)
+	err := internal.HandleBackupValidate(folder, validatedBackupName, output)
+
+	assert.IsType(t, internal.MissingBackupTarsError{}, err)
+	assert.Equal(t, validatedBackupName+"/tar_partitions/part_001.tar.lz4\n"+
+		validatedBackupName+"/tar_partitions/part_003.tar.lz4\n", output.String())
+}
+
+func TestHandleBackupValidate_ReportsMissingPgControlTar(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	tars := []string{"part_001.tar.lz4", "part_002.tar.lz4"}
+	putBackupWithTars(t, folder, tars, tars)
+
+	output := new(bytes.Buffer)
+	err := internal.HandleBackupValidate(folder, validatedBackupName, output)
+
+	assert.IsType(t, internal.MissingBackupTarsError{}, err)
+	assert.Equal(t, validatedBackupName+"/tar_partitions/pg_control.tar.lz4\n", output.String())
+}
+
+func TestHandleBackupValidate_AllTarsPresent(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	tars := []string{"part_001.tar.lz4", "part_002.tar.lz4"}
+	putBackupWithTars(t, folder, tars, append(tars, "pg_control.tar.lz4"))
+
+	output := new(bytes.Buffer)
+	err := internal.HandleBackupValidate(folder, validatedBackupName, output)
+
+	assert.NoError(t, err)
+	assert.Empty(t, output.String())
+}
@@ -0,0 +1,54 @@
+package internal_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+const verifiedBackupName = "base_000000010000000000000002"
+
+func putTarPartition(t *testing.T, backupName string, tarName string, content []byte) *internal.Backup {
+	viper.Set(internal.DownloadConcurrencySetting, "1")
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	baseBackupFolder := folder.GetSubFolder(utility.BaseBackupPath)
+	err := baseBackupFolder.PutObject(backupName+internal.TarPartitionFolderName+tarName, bytes.NewReader(content))
+	assert.NoError(t, err)
+	return internal.NewBackup(baseBackupFolder, backupName)
+}
+
+func makeTarBytes(size int64) []byte {
+	tarBuffer := &bytes.Buffer{}
+	testtools.CreateTar(tarBuffer, &io.LimitedReader{R: testtools.NewStrideByteReader(10), N: size})
+	return tarBuffer.Bytes()
+}
+
+func TestVerifyBackup_ReadableBackup(t *testing.T) {
+	backup := putTarPartition(t, verifiedBackupName, "part_1.tar", makeTarBytes(4096))
+
+	tarInterpreter, err := internal.VerifyBackup(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), tarInterpreter.VerifiedFiles)
+	assert.Equal(t, int64(4096), tarInterpreter.VerifiedBytes)
+}
+
+func TestVerifyBackup_TruncatedTar(t *testing.T) {
+	minWait, maxWait := internal.MinExtractRetryWait, internal.MaxExtractRetryWait
+	internal.MinExtractRetryWait, internal.MaxExtractRetryWait = time.Millisecond, time.Millisecond
+	defer func() {
+		internal.MinExtractRetryWait, internal.MaxExtractRetryWait = minWait, maxWait
+	}()
+
+	tarBytes := makeTarBytes(4096)
+	backup := putTarPartition(t, verifiedBackupName, "part_1.tar", tarBytes[:2048])
+
+	_, err := internal.VerifyBackup(backup)
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/utility"
+)
+
+// WalRangeGap is a run of consecutive WAL segments missing in storage
+type WalRangeGap struct {
+	From WalSegmentDescription
+	To   WalSegmentDescription
+}
+
+func (gap WalRangeGap) String() string {
+	if gap.From == gap.To {
+		return gap.From.GetFileName()
+	}
+	return fmt.Sprintf("%s - %s", gap.From.GetFileName(), gap.To.GetFileName())
+}
+
+// FindBackupWalRangeGaps checks that WAL archive contains every segment needed to restore backup
+// up to targetLsn on targetTimeline. WAL is required at least up to the backup finish LSN,
+// so targetLsn before it is ignored. Zero targetTimeline means the timeline of the backup.
+// Gaps are returned in chronological order, empty result means that the range is contiguous.
+func FindBackupWalRangeGaps(rootFolder storage.Folder, backup *Backup,
+	targetLsn uint64, targetTimeline uint32) ([]WalRangeGap, error) {
+	meta, err := backup.fetchMeta()
+	if err != nil {
+		return nil, err
+	}
+	if targetTimeline == 0 {
+		targetTimeline, err = getBackupTimeline(backup.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if targetLsn < meta.FinishLsn {
+		targetLsn = meta.FinishLsn
+	}
+	startSegmentNo := newWalSegmentNo(meta.StartLsn)
+	endSegment := WalSegmentDescription{Number: newWalSegmentNo(targetLsn - 1), Timeline: targetTimeline}
+
+	walFolder := rootFolder.GetSubFolder(utility.WalPath)
+	storageFileNames, err := getFolderFilenames(walFolder)
+	if err != nil {
+		return nil, err
+	}
+	storageSegments := getSegmentsFromFiles(storageFileNames)
+	timelineSwitchMap, err := createTimelineSwitchMap(targetTimeline, walFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	// runner never checks the segment it starts from, so it is checked here
+	scanner := NewWalSegmentScanner(NewWalSegmentRunner(endSegment, storageSegments, startSegmentNo, timelineSwitchMap))
+	if storageSegments[endSegment] {
+		scanner.AddScannedSegment(endSegment, Found)
+	} else {
+		scanner.AddScannedSegment(endSegment, Lost)
+	}
+	err = scanner.Scan(SegmentScanConfig{UnlimitedScan: true, MissingSegmentStatus: Lost})
+	if err != nil {
+		return nil, err
+	}
+	return collapseMissingSegmentsToGaps(scanner.GetMissingSegmentsDescriptions()), nil
+}
+
+// collapseMissingSegmentsToGaps expects segments in reversed chronological order, as WalSegmentScanner yields them
+func collapseMissingSegmentsToGaps(missingSegments []WalSegmentDescription) []WalRangeGap {
+	gaps := make([]WalRangeGap, 0)
+	for i := len(missingSegments) - 1; i >= 0; i-- {
+		segment := missingSegments[i]
+		if len(gaps) > 0 && gaps[len(gaps)-1].To.Number.next() == segment.Number {
+			gaps[len(gaps)-1].To = segment
+			continue
+		}
+		gaps = append(gaps, WalRangeGap{From: segment, To: segment})
+	}
+	return gaps
+}
@@ -0,0 +1,63 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+const walRangeBackupName = "base_000000010000000000000002"
+
+func prepareWalRangeFolder(t *testing.T, segmentNumbers []internal.WalSegmentNo) (storage.Folder, *internal.Backup) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	baseBackupFolder := folder.GetSubFolder(utility.BaseBackupPath)
+	meta := internal.ExtendedMetadataDto{
+		StartLsn:  2 * internal.WalSegmentSize,
+		FinishLsn: 3*internal.WalSegmentSize + 100,
+	}
+	metaBytes, err := json.Marshal(meta)
+	assert.NoError(t, err)
+	err = baseBackupFolder.PutObject(walRangeBackupName+"/"+utility.MetadataFileName, bytes.NewReader(metaBytes))
+	assert.NoError(t, err)
+
+	walFolder := folder.GetSubFolder(utility.WalPath)
+	for _, segmentNo := range segmentNumbers {
+		segment := internal.WalSegmentDescription{Number: segmentNo, Timeline: 1}
+		err = walFolder.PutObject(segment.GetFileName()+".lz4", &bytes.Buffer{})
+		assert.NoError(t, err)
+	}
+	return folder, internal.NewBackup(baseBackupFolder, walRangeBackupName)
+}
+
+func TestFindBackupWalRangeGaps_Contiguous(t *testing.T) {
+	folder, backup := prepareWalRangeFolder(t, []internal.WalSegmentNo{2, 3, 4, 5})
+
+	gaps, err := internal.FindBackupWalRangeGaps(folder, backup, 5*internal.WalSegmentSize+10, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, gaps)
+}
+
+func TestFindBackupWalRangeGaps_MissingSegment(t *testing.T) {
+	folder, backup := prepareWalRangeFolder(t, []internal.WalSegmentNo{2, 3, 5, 8})
+
+	gaps, err := internal.FindBackupWalRangeGaps(folder, backup, 8*internal.WalSegmentSize+10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(gaps))
+	assert.Equal(t, "000000010000000000000004", gaps[0].String())
+	assert.Equal(t, "000000010000000000000006 - 000000010000000000000007", gaps[1].String())
+}
+
+func TestFindBackupWalRangeGaps_TargetBeforeBackupFinish(t *testing.T) {
+	folder, backup := prepareWalRangeFolder(t, []internal.WalSegmentNo{2})
+
+	gaps, err := internal.FindBackupWalRangeGaps(folder, backup, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(gaps))
+	assert.Equal(t, internal.WalSegmentNo(3), gaps[0].From.Number)
+}
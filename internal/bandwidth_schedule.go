@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal/ioextensions"
+	"github.com/wal-g/wal-g/internal/limited"
+	"golang.org/x/time/rate"
+)
+
+const bandwidthScheduleTimeFormat = "15:04"
+
+// FetchLimiter limits aggregate read rate of backup fetch according to WALG_FETCH_RATE_LIMIT_SCHEDULE
+var FetchLimiter *ScheduledLimiter
+
+type InvalidBandwidthScheduleError struct {
+	error
+}
+
+func newInvalidBandwidthScheduleError(window string, reason string) InvalidBandwidthScheduleError {
+	return InvalidBandwidthScheduleError{errors.Errorf(
+		"invalid bandwidth schedule window '%s': %s, expected format is 'HH:MM-HH:MM=bytes_per_second'", window, reason)}
+}
+
+func (err InvalidBandwidthScheduleError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// BandwidthWindow limits rate from From till To local time of day. Window may span midnight.
+type BandwidthWindow struct {
+	From  time.Duration
+	To    time.Duration
+	Limit int64
+}
+
+func (window BandwidthWindow) contains(timeOfDay time.Duration) bool {
+	if window.From <= window.To {
+		return window.From <= timeOfDay && timeOfDay < window.To
+	}
+	return timeOfDay >= window.From || timeOfDay < window.To
+}
+
+// ParseBandwidthSchedule parses comma separated windows, e.g. "09:00-18:00=1048576,18:00-20:00=10485760".
+// Rate is not limited outside of windows, the first matching window wins.
+func ParseBandwidthSchedule(schedule string) ([]BandwidthWindow, error) {
+	windows := make([]BandwidthWindow, 0)
+	for _, windowString := range strings.Split(schedule, ",") {
+		windowString = strings.TrimSpace(windowString)
+		if windowString == "" {
+			continue
+		}
+		interval, limitString, found := cutString(windowString, "=")
+		if !found {
+			return nil, newInvalidBandwidthScheduleError(windowString, "no limit")
+		}
+		fromString, toString, found := cutString(interval, "-")
+		if !found {
+			return nil, newInvalidBandwidthScheduleError(windowString, "no time interval")
+		}
+		from, err := parseTimeOfDay(fromString)
+		if err != nil {
+			return nil, newInvalidBandwidthScheduleError(windowString, err.Error())
+		}
+		to, err := parseTimeOfDay(toString)
+		if err != nil {
+			return nil, newInvalidBandwidthScheduleError(windowString, err.Error())
+		}
+		limit, err := strconv.ParseInt(strings.TrimSpace(limitString), 10, 64)
+		if err != nil || limit <= 0 {
+			return nil, newInvalidBandwidthScheduleError(windowString, "limit should be a positive integer")
+		}
+		windows = append(windows, BandwidthWindow{from, to, limit})
+	}
+	return windows, nil
+}
+
+func cutString(s, separator string) (before, after string, found bool) {
+	if i := strings.Index(s, separator); i >= 0 {
+		return s[:i], s[i+len(separator):], true
+	}
+	return s, "", false
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	parsed, err := time.Parse(bandwidthScheduleTimeFormat, strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// ScheduledLimiter switches limit of a shared rate.Limiter when schedule windows change
+type ScheduledLimiter struct {
+	windows      []BandwidthWindow
+	now          func() time.Time
+	limiter      *rate.Limiter
+	mutex        sync.Mutex
+	currentLimit int64
+}
+
+func NewScheduledLimiter(windows []BandwidthWindow, now func() time.Time) *ScheduledLimiter {
+	scheduledLimiter := &ScheduledLimiter{
+		windows:      windows,
+		now:          now,
+		limiter:      rate.NewLimiter(rate.Inf, int(DefaultDataBurstRateLimit)),
+		currentLimit: -1,
+	}
+	scheduledLimiter.update()
+	return scheduledLimiter
+}
+
+// CurrentLimit returns limit in bytes per second, zero means that rate is not limited
+func (scheduledLimiter *ScheduledLimiter) CurrentLimit() int64 {
+	scheduledLimiter.mutex.Lock()
+	defer scheduledLimiter.mutex.Unlock()
+	return scheduledLimiter.currentLimit
+}
+
+func (scheduledLimiter *ScheduledLimiter) update() {
+	now := scheduledLimiter.now()
+	year, month, day := now.Date()
+	timeOfDay := now.Sub(time.Date(year, month, day, 0, 0, 0, 0, now.Location()))
+	limit := int64(0)
+	for _, window := range scheduledLimiter.windows {
+		if window.contains(timeOfDay) {
+			limit = window.Limit
+			break
+		}
+	}
+
+	scheduledLimiter.mutex.Lock()
+	defer scheduledLimiter.mutex.Unlock()
+	if limit == scheduledLimiter.currentLimit {
+		return
+	}
+	scheduledLimiter.currentLimit = limit
+	if limit == 0 {
+		tracelog.InfoLogger.Println("Fetch rate is not limited by schedule")
+		scheduledLimiter.limiter.SetLimitAt(now, rate.Inf)
+		scheduledLimiter.limiter.SetBurstAt(now, int(DefaultDataBurstRateLimit))
+		return
+	}
+	tracelog.InfoLogger.Printf("Fetch rate is limited by schedule to %d bytes per second\n", limit)
+	scheduledLimiter.limiter.SetLimitAt(now, rate.Limit(limit))
+	scheduledLimiter.limiter.SetBurstAt(now, int(limit+DefaultDataBurstRateLimit))
+}
+
+type scheduledLimitReader struct {
+	reader           io.Reader
+	scheduledLimiter *ScheduledLimiter
+}
+
+func (reader *scheduledLimitReader) Read(buf []byte) (int, error) {
+	reader.scheduledLimiter.update()
+	return reader.reader.Read(buf)
+}
+
+// NewFetchLimitReadCloser returns a reader that is rate limited by FetchLimiter
+func NewFetchLimitReadCloser(readCloser io.ReadCloser) io.ReadCloser {
+	if FetchLimiter == nil {
+		return readCloser
+	}
+	limitedReader := limited.NewReader(readCloser, FetchLimiter.limiter)
+	return &ioextensions.ReadCascadeCloser{
+		Reader: &scheduledLimitReader{limitedReader, FetchLimiter},
+		Closer: readCloser,
+	}
+}
@@ -0,0 +1,53 @@
+package internal_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+)
+
+func TestParseBandwidthSchedule(t *testing.T) {
+	windows, err := internal.ParseBandwidthSchedule("09:00-18:00=1048576, 22:30-06:00=2048")
+	assert.NoError(t, err)
+	assert.Equal(t, []internal.BandwidthWindow{
+		{From: 9 * time.Hour, To: 18 * time.Hour, Limit: 1048576},
+		{From: 22*time.Hour + 30*time.Minute, To: 6 * time.Hour, Limit: 2048},
+	}, windows)
+}
+
+func TestParseBandwidthSchedule_Invalid(t *testing.T) {
+	for _, schedule := range []string{"09:00-18:00", "09:00=100", "9am-18:00=100", "09:00-18:00=0", "09:00-18:00=fast"} {
+		_, err := internal.ParseBandwidthSchedule(schedule)
+		assert.IsType(t, internal.InvalidBandwidthScheduleError{}, err, schedule)
+	}
+}
+
+func TestScheduledLimiter_SwitchesLimitOnWindowBoundary(t *testing.T) {
+	windows, err := internal.ParseBandwidthSchedule("09:00-18:00=1048576,22:00-06:00=2048")
+	assert.NoError(t, err)
+	now := time.Date(2020, 9, 1, 8, 59, 0, 0, time.Local)
+	scheduledLimiter := internal.NewScheduledLimiter(windows, func() time.Time { return now })
+	internal.FetchLimiter = scheduledLimiter
+	defer func() { internal.FetchLimiter = nil }()
+	reader := internal.NewFetchLimitReadCloser(ioutil.NopCloser(bytes.NewReader(make([]byte, 100))))
+	assert.Equal(t, int64(0), scheduledLimiter.CurrentLimit())
+
+	now = now.Add(time.Minute)
+	_, err = reader.Read(make([]byte, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1048576), scheduledLimiter.CurrentLimit())
+
+	now = time.Date(2020, 9, 1, 18, 0, 0, 0, time.Local)
+	_, err = reader.Read(make([]byte, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), scheduledLimiter.CurrentLimit())
+
+	now = time.Date(2020, 9, 2, 1, 0, 0, 0, time.Local)
+	_, err = reader.Read(make([]byte, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2048), scheduledLimiter.CurrentLimit())
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"github.com/wal-g/tracelog"
 	"github.com/wal-g/wal-g/utility"
 )
 
@@ -21,10 +22,11 @@ func HandleCatchupPush(uploader *WalUploader, archiveDirectory string, fromLSN u
 
 	extendExcludedFiles()
 
-	createAndPushBackup(
+	err := createAndPushBackup(
 		uploader,
 		archiveDirectory, utility.CatchupPath,
 		"", fakePreviousBackupSentinelDto,
 		false, true, 0,
 		false, false, RegularComposer)
+	tracelog.ErrorLogger.FatalOnError(err)
 }
@@ -17,44 +17,61 @@ import (
 )
 
 const (
-	DownloadConcurrencySetting   = "WALG_DOWNLOAD_CONCURRENCY"
-	UploadConcurrencySetting     = "WALG_UPLOAD_CONCURRENCY"
-	UploadDiskConcurrencySetting = "WALG_UPLOAD_DISK_CONCURRENCY"
-	UploadQueueSetting           = "WALG_UPLOAD_QUEUE"
-	SentinelUserDataSetting      = "WALG_SENTINEL_USER_DATA"
-	PreventWalOverwriteSetting   = "WALG_PREVENT_WAL_OVERWRITE"
-	DeltaMaxStepsSetting         = "WALG_DELTA_MAX_STEPS"
-	DeltaOriginSetting           = "WALG_DELTA_ORIGIN"
-	CompressionMethodSetting     = "WALG_COMPRESSION_METHOD"
-	DiskRateLimitSetting         = "WALG_DISK_RATE_LIMIT"
-	NetworkRateLimitSetting      = "WALG_NETWORK_RATE_LIMIT"
-	UseWalDeltaSetting           = "WALG_USE_WAL_DELTA"
-	UseReverseUnpackSetting      = "WALG_USE_REVERSE_UNPACK"
-	SkipRedundantTarsSetting     = "WALG_SKIP_REDUNDANT_TARS"
-	VerifyPageChecksumsSetting   = "WALG_VERIFY_PAGE_CHECKSUMS"
-	StoreAllCorruptBlocksSetting = "WALG_STORE_ALL_CORRUPT_BLOCKS"
-	UseRatingComposerSetting     = "WALG_USE_RATING_COMPOSER"
-	LogLevelSetting              = "WALG_LOG_LEVEL"
-	TarSizeThresholdSetting      = "WALG_TAR_SIZE_THRESHOLD"
-	CseKmsIDSetting              = "WALG_CSE_KMS_ID"
-	CseKmsRegionSetting          = "WALG_CSE_KMS_REGION"
-	LibsodiumKeySetting          = "WALG_LIBSODIUM_KEY"
-	LibsodiumKeyPathSetting      = "WALG_LIBSODIUM_KEY_PATH"
-	GpgKeyIDSetting              = "GPG_KEY_ID"
-	PgpKeySetting                = "WALG_PGP_KEY"
-	PgpKeyPathSetting            = "WALG_PGP_KEY_PATH"
-	PgpKeyPassphraseSetting      = "WALG_PGP_KEY_PASSPHRASE"
-	PgDataSetting                = "PGDATA"
-	UserSetting                  = "USER" // TODO : do something with it
-	PgPortSetting                = "PGPORT"
-	PgUserSetting                = "PGUSER"
-	PgHostSetting                = "PGHOST"
-	PgPasswordSetting            = "PGPASSWORD"
-	PgDatabaseSetting            = "PGDATABASE"
-	PgSslModeSetting             = "PGSSLMODE"
-	TotalBgUploadedLimit         = "TOTAL_BG_UPLOADED_LIMIT"
-	NameStreamCreateCmd          = "WALG_STREAM_CREATE_COMMAND"
-	NameStreamRestoreCmd         = "WALG_STREAM_RESTORE_COMMAND"
+	DownloadConcurrencySetting    = "WALG_DOWNLOAD_CONCURRENCY"
+	UploadConcurrencySetting      = "WALG_UPLOAD_CONCURRENCY"
+	UploadDiskConcurrencySetting  = "WALG_UPLOAD_DISK_CONCURRENCY"
+	UploadQueueSetting            = "WALG_UPLOAD_QUEUE"
+	SentinelUserDataSetting       = "WALG_SENTINEL_USER_DATA"
+	PreventWalOverwriteSetting    = "WALG_PREVENT_WAL_OVERWRITE"
+	DeltaMaxStepsSetting          = "WALG_DELTA_MAX_STEPS"
+	DeltaOriginSetting            = "WALG_DELTA_ORIGIN"
+	CompressionMethodSetting      = "WALG_COMPRESSION_METHOD"
+	DiskRateLimitSetting          = "WALG_DISK_RATE_LIMIT"
+	NetworkRateLimitSetting       = "WALG_NETWORK_RATE_LIMIT"
+	UseWalDeltaSetting            = "WALG_USE_WAL_DELTA"
+	UseReverseUnpackSetting       = "WALG_USE_REVERSE_UNPACK"
+	SkipRedundantTarsSetting      = "WALG_SKIP_REDUNDANT_TARS"
+	VerifyPageChecksumsSetting    = "WALG_VERIFY_PAGE_CHECKSUMS"
+	StoreAllCorruptBlocksSetting  = "WALG_STORE_ALL_CORRUPT_BLOCKS"
+	UseRatingComposerSetting      = "WALG_USE_RATING_COMPOSER"
+	LogLevelSetting               = "WALG_LOG_LEVEL"
+	TarSizeThresholdSetting       = "WALG_TAR_SIZE_THRESHOLD"
+	CseKmsIDSetting               = "WALG_CSE_KMS_ID"
+	CseKmsRegionSetting           = "WALG_CSE_KMS_REGION"
+	LibsodiumKeySetting           = "WALG_LIBSODIUM_KEY"
+	LibsodiumKeyPathSetting       = "WALG_LIBSODIUM_KEY_PATH"
+	GpgKeyIDSetting               = "GPG_KEY_ID"
+	PgpKeySetting                 = "WALG_PGP_KEY"
+	PgpKeyPathSetting             = "WALG_PGP_KEY_PATH"
+	PgpKeyPassphraseSetting       = "WALG_PGP_KEY_PASSPHRASE"
+	PgDataSetting                 = "PGDATA"
+	UserSetting                   = "USER" // TODO : do something with it
+	PgPortSetting                 = "PGPORT"
+	PgUserSetting                 = "PGUSER"
+	PgHostSetting                 = "PGHOST"
+	PgPasswordSetting             = "PGPASSWORD"
+	PgDatabaseSetting             = "PGDATABASE"
+	PgSslModeSetting              = "PGSSLMODE"
+	TotalBgUploadedLimit          = "TOTAL_BG_UPLOADED_LIMIT"
+	NameStreamCreateCmd           = "WALG_STREAM_CREATE_COMMAND"
+	NameStreamRestoreCmd          = "WALG_STREAM_RESTORE_COMMAND"
+	CopyRenameRulesSetting        = "WALG_COPY_RENAME_RULES"
+	CopyLogDirectorySetting       = "WALG_COPY_LOG_DIRECTORY"
+	StorageLockTTLSetting         = "WALG_STORAGE_LOCK_TTL"
+	FetchRateLimitScheduleSetting = "WALG_FETCH_RATE_LIMIT_SCHEDULE"
+	CompressMetadataSetting       = "WALG_COMPRESS_METADATA"
+	StartupRetriesSetting         = "WALG_STARTUP_RETRIES"
+	CopyMaxObjectsSetting         = "WALG_COPY_MAX_OBJECTS"
+	LowercaseObjectNamesSetting   = "WALG_LOWERCASE_OBJECT_NAMES"
+	DeleteBatchSizeSetting        = "WALG_DELETE_BATCH_SIZE"
+	DeleteConcurrencySetting      = "WALG_DELETE_CONCURRENCY"
+	ProtectedObjectsSetting       = "WALG_PROTECTED_OBJECTS"
+	HeadConcurrencySetting        = "WALG_HEAD_CONCURRENCY"
+	RetryBaseDelaySetting         = "WALG_RETRY_BASE_DELAY"
+	RetryMaxDelaySetting          = "WALG_RETRY_MAX_DELAY"
+	RetryMultiplierSetting        = "WALG_RETRY_MULTIPLIER"
+	RetryJitterSetting            = "WALG_RETRY_JITTER"
+	FetchHeartbeatIntervalSetting = "WALG_FETCH_HEARTBEAT_INTERVAL"
 
 	MongoDBUriSetting               = "MONGODB_URI"
 	MongoDBLastWriteUpdateInterval  = "MONGODB_LAST_WRITE_UPDATE_INTERVAL"
@@ -119,33 +136,50 @@ var (
 
 	AllowedSettings = map[string]bool{
 		// WAL-G core
-		DownloadConcurrencySetting:   true,
-		UploadConcurrencySetting:     true,
-		UploadDiskConcurrencySetting: true,
-		UploadQueueSetting:           true,
-		SentinelUserDataSetting:      true,
-		PreventWalOverwriteSetting:   true,
-		DeltaMaxStepsSetting:         true,
-		DeltaOriginSetting:           true,
-		CompressionMethodSetting:     true,
-		DiskRateLimitSetting:         true,
-		NetworkRateLimitSetting:      true,
-		UseWalDeltaSetting:           true,
-		LogLevelSetting:              true,
-		TarSizeThresholdSetting:      true,
-		"WALG_" + GpgKeyIDSetting:    true,
-		"WALE_" + GpgKeyIDSetting:    true,
-		PgpKeySetting:                true,
-		PgpKeyPathSetting:            true,
-		PgpKeyPassphraseSetting:      true,
-		TotalBgUploadedLimit:         true,
-		NameStreamCreateCmd:          true,
-		NameStreamRestoreCmd:         true,
-		UseReverseUnpackSetting:      true,
-		SkipRedundantTarsSetting:     true,
-		VerifyPageChecksumsSetting:   true,
-		StoreAllCorruptBlocksSetting: true,
-		UseRatingComposerSetting:     true,
+		DownloadConcurrencySetting:    true,
+		UploadConcurrencySetting:      true,
+		UploadDiskConcurrencySetting:  true,
+		UploadQueueSetting:            true,
+		SentinelUserDataSetting:       true,
+		PreventWalOverwriteSetting:    true,
+		DeltaMaxStepsSetting:          true,
+		DeltaOriginSetting:            true,
+		CompressionMethodSetting:      true,
+		DiskRateLimitSetting:          true,
+		NetworkRateLimitSetting:       true,
+		UseWalDeltaSetting:            true,
+		LogLevelSetting:               true,
+		TarSizeThresholdSetting:       true,
+		"WALG_" + GpgKeyIDSetting:     true,
+		"WALE_" + GpgKeyIDSetting:     true,
+		PgpKeySetting:                 true,
+		PgpKeyPathSetting:             true,
+		PgpKeyPassphraseSetting:       true,
+		TotalBgUploadedLimit:          true,
+		NameStreamCreateCmd:           true,
+		NameStreamRestoreCmd:          true,
+		UseReverseUnpackSetting:       true,
+		SkipRedundantTarsSetting:      true,
+		VerifyPageChecksumsSetting:    true,
+		StoreAllCorruptBlocksSetting:  true,
+		UseRatingComposerSetting:      true,
+		CopyRenameRulesSetting:        true,
+		CopyLogDirectorySetting:       true,
+		StorageLockTTLSetting:         true,
+		FetchRateLimitScheduleSetting: true,
+		CompressMetadataSetting:       true,
+		StartupRetriesSetting:         true,
+		CopyMaxObjectsSetting:         true,
+		LowercaseObjectNamesSetting:   true,
+		DeleteBatchSizeSetting:        true,
+		DeleteConcurrencySetting:      true,
+		ProtectedObjectsSetting:       true,
+		HeadConcurrencySetting:        true,
+		RetryBaseDelaySetting:         true,
+		RetryMaxDelaySetting:          true,
+		RetryMultiplierSetting:        true,
+		RetryJitterSetting:            true,
+		FetchHeartbeatIntervalSetting: true,
 
 		// Postgres
 		PgPortSetting:     true,
@@ -256,8 +290,12 @@ func isAllowedSetting(setting string, AllowedSettings map[string]bool) (exists b
 
 // GetSetting extract setting by key if key is set, return empty string otherwise
 func GetSetting(key string) (value string, ok bool) {
-	if viper.IsSet(key) {
-		return viper.GetString(key), true
+	return getSettingFrom(key, viper.GetViper())
+}
+
+func getSettingFrom(key string, config *viper.Viper) (value string, ok bool) {
+	if config.IsSet(key) {
+		return config.GetString(key), true
 	}
 	return "", false
 }
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal/compression"
+)
+
+const (
+	s3SseSetting         = "S3_SSE"
+	s3SseKmsIDSetting    = "S3_SSE_KMS_ID"
+	s3MaxPartSizeSetting = "S3_MAX_PART_SIZE"
+	s3SseKmsValue        = "aws:kms"
+)
+
+type InvalidSettingsError struct {
+	error
+}
+
+func newInvalidSettingsError(problems []string) InvalidSettingsError {
+	return InvalidSettingsError{errors.Errorf("Invalid configuration:\n  %s", strings.Join(problems, "\n  "))}
+}
+
+func (err InvalidSettingsError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// settingsValidators check interdependent settings and return human readable problems
+var settingsValidators = []func() []string{
+	validateS3ServerSideEncryption,
+	validateS3MaxPartSize,
+	validateConcurrencySettings,
+	validateRateLimitSettings,
+	validateCompressionMethod,
+	validateFetchRateLimitSchedule,
+	validateStartupRetries,
+	validateCopyMaxObjects,
+	validateDeleteBatchSize,
+	validateDeleteConcurrency,
+	validateHeadConcurrency,
+	validateRetryBackoff,
+	validateFetchHeartbeatInterval,
+	validateProtectedObjects,
+}
+
+// ValidateSettings cross-validates settings which can't be checked one by one,
+// so that misconfiguration is reported before a long operation starts.
+// All found problems are reported at once.
+func ValidateSettings() error {
+	warnAboutShadowedCrypterSettings()
+	problems := make([]string, 0)
+	for _, validator := range settingsValidators {
+		problems = append(problems, validator()...)
+	}
+	if len(problems) > 0 {
+		return newInvalidSettingsError(problems)
+	}
+	return nil
+}
+
+func validateS3ServerSideEncryption() []string {
+	sse, _ := getWaleCompatibleSetting(s3SseSetting)
+	sseKmsID, _ := getWaleCompatibleSetting(s3SseKmsIDSetting)
+	if sseKmsID != "" && sse != s3SseKmsValue {
+		return []string{fmt.Sprintf("WALG_%s is set, but WALG_%s is '%s': set WALG_%s=%s or unset WALG_%s",
+			s3SseKmsIDSetting, s3SseSetting, sse, s3SseSetting, s3SseKmsValue, s3SseKmsIDSetting)}
+	}
+	if sse == s3SseKmsValue && sseKmsID == "" {
+		return []string{fmt.Sprintf("WALG_%s is '%s', but WALG_%s is not set: set it to the KMS key ID",
+			s3SseSetting, s3SseKmsValue, s3SseKmsIDSetting)}
+	}
+	return nil
+}
+
+func validateS3MaxPartSize() []string {
+	maxPartSize, ok := getWaleCompatibleSetting(s3MaxPartSizeSetting)
+	if !ok {
+		return nil
+	}
+	if value, err := strconv.Atoi(maxPartSize); err != nil || value <= 0 {
+		return []string{fmt.Sprintf("WALG_%s should be a positive number of bytes, but is '%s'",
+			s3MaxPartSizeSetting, maxPartSize)}
+	}
+	return nil
+}
+
+func validateConcurrencySettings() (problems []string) {
+	for _, setting := range []string{DownloadConcurrencySetting, UploadConcurrencySetting,
+		UploadDiskConcurrencySetting, UploadQueueSetting} {
+		value, ok := GetSetting(setting)
+		if !ok {
+			continue
+		}
+		if concurrency, err := strconv.Atoi(value); err != nil || concurrency < MinAllowedConcurrency {
+			problems = append(problems, fmt.Sprintf("%s should be a positive integer, but is '%s'", setting, value))
+		}
+	}
+	return
+}
+
+func validateRateLimitSettings() (problems []string) {
+	for _, setting := range []string{DiskRateLimitSetting, NetworkRateLimitSetting} {
+		value, ok := GetSetting(setting)
+		if !ok {
+			continue
+		}
+		if limit, err := strconv.ParseInt(value, 10, 64); err != nil || limit <= 0 {
+			problems = append(problems, fmt.Sprintf("%s should be a positive number of bytes per second, but is '%s'",
+				setting, value))
+		}
+	}
+	return
+}
+
+func validateCompressionMethod() []string {
+	method, ok := GetSetting(CompressionMethodSetting)
+	if !ok {
+		return nil
+	}
+	if _, ok := compression.Compressors[method]; !ok {
+		return []string{fmt.Sprintf("%s is '%s', supported methods are: %v",
+			CompressionMethodSetting, method, compression.CompressingAlgorithms)}
+	}
+	return nil
+}
+
+// warnAboutShadowedCrypterSettings names the key ConfigureCrypter uses when several are set,
+// the others are ignored, which is valid but likely unintended
+func warnAboutShadowedCrypterSettings() {
+	configured := make([]string, 0)
+	for _, setting := range []string{PgpKeySetting, PgpKeyPathSetting} {
+		if viper.IsSet(setting) {
+			configured = append(configured, setting)
+		}
+	}
+	if _, ok := getWaleCompatibleSetting(GpgKeyIDSetting); ok {
+		configured = append(configured, "WALG_"+GpgKeyIDSetting)
+	}
+	for _, setting := range []string{CseKmsIDSetting, LibsodiumKeySetting, LibsodiumKeyPathSetting} {
+		if viper.IsSet(setting) {
+			configured = append(configured, setting)
+		}
+	}
+	if len(configured) > 1 {
+		tracelog.WarningLogger.Printf("Several encryption keys are set: %s, only %s is used\n",
+			strings.Join(configured, ", "), configured[0])
+	}
+}
+
+func validateFetchRateLimitSchedule() []string {
+	schedule, ok := GetSetting(FetchRateLimitScheduleSetting)
+	if !ok {
+		return nil
+	}
+	if _, err := ParseBandwidthSchedule(schedule); err != nil {
+		return []string{fmt.Sprintf("%s: %v", FetchRateLimitScheduleSetting, err)}
+	}
+	return nil
+}
+
+func validateStartupRetries() []string {
+	if _, err := getStartupRetries(); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func validateCopyMaxObjects() []string {
+	if _, err := getCopyMaxObjects(); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func validateDeleteBatchSize() []string {
+	if _, err := getDeleteBatchSize(); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func validateDeleteConcurrency() []string {
+	if _, err := getDeleteConcurrency(); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func validateProtectedObjects() []string {
+	if _, err := getProtectedObjectGlobs(); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func validateHeadConcurrency() []string {
+	if _, err := getHeadConcurrency(); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func validateRetryBackoff() []string {
+	if _, err := getRetryBackoff(MinStartupRetryWait, MaxStartupRetryWait); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
+
+func validateFetchHeartbeatInterval() []string {
+	if _, err := getFetchHeartbeatInterval(); err != nil {
+		return []string{err.Error()}
+	}
+	return nil
+}
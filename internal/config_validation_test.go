@@ -0,0 +1,73 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+)
+
+func withSettings(t *testing.T, settings map[string]string, check func()) {
+	for setting, value := range settings {
+		viper.Set(setting, value)
+	}
+	defer func() {
+		for setting := range settings {
+			viper.Set(setting, nil)
+		}
+	}()
+	check()
+}
+
+func TestValidateSettings_DefaultsAreValid(t *testing.T) {
+	assert.NoError(t, internal.ValidateSettings())
+}
+
+func TestValidateSettings_KmsIDWithoutKmsSse(t *testing.T) {
+	withSettings(t, map[string]string{"WALG_S3_SSE_KMS_ID": "key-id"}, func() {
+		err := internal.ValidateSettings()
+		assert.IsType(t, internal.InvalidSettingsError{}, err)
+		assert.Contains(t, err.Error(), "WALG_S3_SSE_KMS_ID is set")
+	})
+}
+
+func TestValidateSettings_KmsSseWithoutKmsID(t *testing.T) {
+	withSettings(t, map[string]string{"WALG_S3_SSE": "aws:kms"}, func() {
+		err := internal.ValidateSettings()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "WALG_S3_SSE_KMS_ID is not set")
+	})
+}
+
+func TestValidateSettings_KmsSseWithKmsID(t *testing.T) {
+	withSettings(t, map[string]string{"WALG_S3_SSE": "aws:kms", "WALG_S3_SSE_KMS_ID": "key-id"}, func() {
+		assert.NoError(t, internal.ValidateSettings())
+	})
+}
+
+func TestValidateSettings_AggregatesProblems(t *testing.T) {
+	withSettings(t, map[string]string{
+		internal.DownloadConcurrencySetting: "0",
+		internal.CompressionMethodSetting:   "zip",
+		"WALG_S3_MAX_PART_SIZE":             "big",
+		internal.NetworkRateLimitSetting:    "-1",
+	}, func() {
+		err := internal.ValidateSettings()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), internal.DownloadConcurrencySetting)
+		assert.Contains(t, err.Error(), internal.CompressionMethodSetting)
+		assert.Contains(t, err.Error(), "WALG_S3_MAX_PART_SIZE")
+		assert.Contains(t, err.Error(), internal.NetworkRateLimitSetting)
+	})
+}
+
+func TestValidateSettings_SeveralEncryptionKeysAreValid(t *testing.T) {
+	withSettings(t, map[string]string{internal.PgpKeySetting: "key", internal.CseKmsIDSetting: "kms"}, func() {
+		err := internal.ValidateSettings()
+		if err != nil {
+			assert.NotContains(t, err.Error(), internal.PgpKeySetting)
+			assert.NotContains(t, err.Error(), internal.CseKmsIDSetting)
+		}
+	})
+}
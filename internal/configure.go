@@ -107,6 +107,12 @@ func configureLimiters() {
 		netLimit := viper.GetInt64(NetworkRateLimitSetting)
 		NetworkLimiter = rate.NewLimiter(rate.Limit(netLimit), int(netLimit+DefaultDataBurstRateLimit)) // Add 8 pages to possible bursts
 	}
+
+	if viper.IsSet(FetchRateLimitScheduleSetting) {
+		windows, err := ParseBandwidthSchedule(viper.GetString(FetchRateLimitScheduleSetting))
+		tracelog.ErrorLogger.FatalOnError(err)
+		FetchLimiter = NewScheduledLimiter(windows, time.Now)
+	}
 }
 
 // TODO : unit tests
@@ -130,7 +136,32 @@ func ConfigureFolderForSpecificConfig(config *viper.Viper) (storage.Folder, erro
 		if err != nil {
 			return nil, err
 		}
-		return adapter.configureFolder(prefix, settings)
+		folder, err := configureFolderWithRetries(func() (storage.Folder, error) {
+			return adapter.configureFolder(prefix, settings)
+		})
+		if err != nil {
+			return nil, err
+		}
+		deleteBatchSize, err := getDeleteBatchSizeFrom(config)
+		if err != nil {
+			return nil, err
+		}
+		if deleteBatchSize < MaxDeleteBatchSize {
+			folder = NewDeleteBatchingFolder(folder, deleteBatchSize)
+		}
+		headConcurrency, err := getHeadConcurrencyFrom(config)
+		if err != nil {
+			return nil, err
+		}
+		if headConcurrency > 0 {
+			folder = NewHeadLimitingFolder(folder, headConcurrency)
+		}
+		if !config.GetBool(LowercaseObjectNamesSetting) {
+			return folder, nil
+		}
+		tracelog.WarningLogger.Printf("%s is set: object names are lowercased on write and read, "+
+			"existing objects with uppercase letters in names can't be read\n", LowercaseObjectNamesSetting)
+		return NewLowercaseFolder(folder), nil
 	}
 	return nil, newUnconfiguredStorageError(skippedPrefixes)
 }
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCopyInFlightBytes bounds total size of objects copied at the same time
+	DefaultCopyInFlightBytes = int64(256 << 20)
+	// defaultCopyJobsCount is the largest number of objects copied at the same time
+	defaultCopyJobsCount = 8
+)
+
+// copyBudget limits the total size and the number of objects being copied simultaneously.
+// Objects larger than the whole budget are copied alone,
+// objects of unknown size take an equal share of the budget.
+// The jobs limit keeps listings of many small objects from starting a request per object at once.
+type copyBudget struct {
+	limit    int64
+	inFlight int64
+	maxJobs  int
+	jobs     int
+	cond     *sync.Cond
+
+	// During rampUpWindow after rampUpStart the jobs limit grows linearly from one job to maxJobs
+	rampUpStart  time.Time
+	rampUpWindow time.Duration
+	now          func() time.Time
+	stopRampUp   chan struct{}
+}
+
+func newCopyBudget(limit int64) *copyBudget {
+	return &copyBudget{limit: limit, maxJobs: defaultCopyJobsCount, cond: sync.NewCond(&sync.Mutex{}), now: time.Now}
+}
+
+func newRampingCopyBudget(limit int64, window time.Duration) *copyBudget {
+	budget := newCopyBudget(limit)
+	budget.rampUpStart = budget.now()
+	budget.rampUpWindow = window
+	if window > 0 {
+		budget.stopRampUp = make(chan struct{})
+		go budget.wakeDuringRampUp()
+	}
+	return budget
+}
+
+// currentMaxJobs is the jobs limit during ramp up
+func (budget *copyBudget) currentMaxJobs() int {
+	elapsed := budget.now().Sub(budget.rampUpStart)
+	if budget.rampUpWindow <= 0 || elapsed >= budget.rampUpWindow {
+		return budget.maxJobs
+	}
+	return 1 + int(float64(budget.maxJobs-1)*float64(elapsed)/float64(budget.rampUpWindow))
+}
+
+// wakeDuringRampUp makes waiting jobs recheck the growing jobs limit without waiting for a running job to finish
+func (budget *copyBudget) wakeDuringRampUp() {
+	ticker := time.NewTicker(budget.rampUpWindow / time.Duration(budget.maxJobs))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-budget.stopRampUp:
+			return
+		}
+		budget.cond.Broadcast()
+		if budget.now().Sub(budget.rampUpStart) >= budget.rampUpWindow {
+			return
+		}
+	}
+}
+
+// close stops waking waiting jobs during ramp up
+func (budget *copyBudget) close() {
+	if budget.stopRampUp != nil {
+		close(budget.stopRampUp)
+	}
+}
+
+func (budget *copyBudget) cost(size int64) int64 {
+	if size <= 0 {
+		return budget.limit / defaultCopyJobsCount
+	}
+	if size > budget.limit {
+		return budget.limit
+	}
+	return size
+}
+
+// acquire blocks until cost fits in the budget and there is a free job slot, and returns cost
+func (budget *copyBudget) acquire(size int64) int64 {
+	cost := budget.cost(size)
+	budget.cond.L.Lock()
+	defer budget.cond.L.Unlock()
+	for budget.jobs >= budget.currentMaxJobs() || budget.inFlight > 0 && budget.inFlight+cost > budget.limit {
+		budget.cond.Wait()
+	}
+	budget.inFlight += cost
+	budget.jobs++
+	return cost
+}
+
+func (budget *copyBudget) release(cost int64) {
+	budget.cond.L.Lock()
+	budget.inFlight -= cost
+	budget.jobs--
+	budget.cond.L.Unlock()
+	budget.cond.Broadcast()
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyBudget_RampUpGrowsJobsLimitToMax(t *testing.T) {
+	now := time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+	budget := newCopyBudget(800)
+	budget.now = func() time.Time { return now }
+	budget.rampUpStart = now
+	budget.rampUpWindow = 14 * time.Second
+
+	assert.Equal(t, 1, budget.currentMaxJobs())
+	now = now.Add(7 * time.Second)
+	assert.Equal(t, 4, budget.currentMaxJobs())
+	now = now.Add(7 * time.Second)
+	assert.Equal(t, defaultCopyJobsCount, budget.currentMaxJobs())
+	now = now.Add(time.Minute)
+	assert.Equal(t, defaultCopyJobsCount, budget.currentMaxJobs())
+}
+
+func TestCopyBudget_RampUpBlocksSecondJobAtStart(t *testing.T) {
+	now := time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+	budget := newCopyBudget(800)
+	budget.now = func() time.Time { return now }
+	budget.rampUpStart = now
+	budget.rampUpWindow = 10 * time.Second
+
+	cost := budget.acquire(100)
+	acquired := make(chan int64)
+	go func() { acquired <- budget.acquire(100) }()
+	select {
+	case <-acquired:
+		t.Fatal("second job started before ramp up allowed it")
+	case <-time.After(50 * time.Millisecond):
+	}
+	budget.release(cost)
+	assert.Equal(t, int64(100), <-acquired)
+}
+
+func TestCopyBudget_RampUpStartsJobsWhileOthersAreRunning(t *testing.T) {
+	budget := newRampingCopyBudget(800, 100*time.Millisecond)
+	defer budget.close()
+
+	budget.acquire(100)
+	acquired := make(chan int64)
+	go func() { acquired <- budget.acquire(100) }()
+	select {
+	case cost := <-acquired:
+		assert.Equal(t, int64(100), cost)
+	case <-time.After(5 * time.Second):
+		t.Fatal("second job waited for the first one to finish after ramp up")
+	}
+}
+
+func TestCopyBudget_WithoutRampUpUsesFullJobsLimit(t *testing.T) {
+	budget := newCopyBudget(800)
+	assert.Equal(t, defaultCopyJobsCount, budget.currentMaxJobs())
+}
+
+func TestCopyBudget_ManySmallObjectsDoNotExceedJobsLimit(t *testing.T) {
+	budget := newCopyBudget(DefaultCopyInFlightBytes)
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		cost := budget.acquire(100)
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mutex.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			time.Sleep(time.Millisecond)
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			budget.release(cost)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxRunning, defaultCopyJobsCount)
+}
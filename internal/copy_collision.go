@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/tracelog"
+)
+
+// Policies of handling several copied objects with the same target name
+const (
+	CopyCollisionError     = "error"
+	CopyCollisionSkip      = "skip"
+	CopyCollisionOverwrite = "overwrite"
+	CopyCollisionSuffix    = "suffix"
+)
+
+var CopyCollisionPolicies = []string{CopyCollisionError, CopyCollisionSkip, CopyCollisionOverwrite, CopyCollisionSuffix}
+
+type UnknownCopyCollisionPolicyError struct {
+	error
+}
+
+func newUnknownCopyCollisionPolicyError(policy string) UnknownCopyCollisionPolicyError {
+	return UnknownCopyCollisionPolicyError{errors.Errorf("unknown collision policy '%s', expected one of: %s",
+		policy, strings.Join(CopyCollisionPolicies, ", "))}
+}
+
+func (err UnknownCopyCollisionPolicyError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+type CopyTargetCollisionError struct {
+	error
+}
+
+func newCopyTargetCollisionError(targetNames []string) CopyTargetCollisionError {
+	return CopyTargetCollisionError{errors.Errorf("several objects would be copied to the same target: %s",
+		strings.Join(targetNames, ", "))}
+}
+
+func (err CopyTargetCollisionError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// ResolveCopyingInfoCollisions detects infos with the same target name, e.g. after a rename
+// which collapses names, and handles them according to policy:
+// error fails the copy, skip keeps the first object, overwrite keeps the last one
+// and suffix appends a counter to target names of all but the first object.
+func ResolveCopyingInfoCollisions(infos []CopyingInfo, policy string) ([]CopyingInfo, error) {
+	if !isKnownCopyCollisionPolicy(policy) {
+		return nil, newUnknownCopyCollisionPolicyError(policy)
+	}
+	indicesByTarget := make(map[string][]int)
+	collidedTargets := make([]string, 0)
+	for i, info := range infos {
+		indices := indicesByTarget[info.TargetName]
+		if len(indices) == 1 {
+			collidedTargets = append(collidedTargets, info.TargetName)
+		}
+		indicesByTarget[info.TargetName] = append(indices, i)
+	}
+	if len(collidedTargets) == 0 {
+		return infos, nil
+	}
+
+	switch policy {
+	case CopyCollisionError:
+		return nil, newCopyTargetCollisionError(collidedTargets)
+	case CopyCollisionSkip:
+		return keepOneOfCollided(infos, indicesByTarget, func(indices []int) int { return indices[0] }), nil
+	case CopyCollisionOverwrite:
+		return keepOneOfCollided(infos, indicesByTarget, func(indices []int) int { return indices[len(indices)-1] }), nil
+	default:
+		return suffixCollided(infos, indicesByTarget, collidedTargets), nil
+	}
+}
+
+func isKnownCopyCollisionPolicy(policy string) bool {
+	for _, knownPolicy := range CopyCollisionPolicies {
+		if policy == knownPolicy {
+			return true
+		}
+	}
+	return false
+}
+
+func keepOneOfCollided(infos []CopyingInfo, indicesByTarget map[string][]int, choose func([]int) int) []CopyingInfo {
+	resolved := make([]CopyingInfo, 0, len(indicesByTarget))
+	for i, info := range infos {
+		if choose(indicesByTarget[info.TargetName]) != i {
+			tracelog.WarningLogger.Printf("Not copying '%s': another object is copied to '%s'\n",
+				info.Object.GetName(), info.TargetName)
+			continue
+		}
+		resolved = append(resolved, info)
+	}
+	return resolved
+}
+
+func suffixCollided(infos []CopyingInfo, indicesByTarget map[string][]int, collidedTargets []string) []CopyingInfo {
+	resolved := make([]CopyingInfo, len(infos))
+	copy(resolved, infos)
+	for _, targetName := range collidedTargets {
+		counter := 0
+		for _, index := range indicesByTarget[targetName][1:] {
+			newTargetName := targetName
+			for _, exists := indicesByTarget[newTargetName]; exists; _, exists = indicesByTarget[newTargetName] {
+				counter++
+				newTargetName = fmt.Sprintf("%s_%d", targetName, counter)
+			}
+			indicesByTarget[newTargetName] = []int{index}
+			tracelog.WarningLogger.Printf("Copying '%s' to '%s': another object is copied to '%s'\n",
+				resolved[index].Object.GetName(), newTargetName, targetName)
+			resolved[index].TargetName = newTargetName
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,68 @@
+package internal_test
+
+import (
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+)
+
+// makeCollidedCopyingInfos returns infos where 'a/x' and 'b/x' are both renamed to 'x'
+func makeCollidedCopyingInfos() []internal.CopyingInfo {
+	var names = []string{"a/x", "b/x", "c/y"}
+	var infos = make([]internal.CopyingInfo, 0, len(names))
+	for _, name := range names {
+		infos = append(infos, internal.CopyingInfo{Object: storage.NewLocalObject(name, time.Now(), 1), TargetName: name})
+	}
+	internal.RenameCopyingInfos(infos, path.Base)
+	return infos
+}
+
+func getCopyingInfosMapping(infos []internal.CopyingInfo) map[string]string {
+	mapping := make(map[string]string)
+	for _, info := range infos {
+		mapping[info.Object.GetName()] = info.TargetName
+	}
+	return mapping
+}
+
+func TestResolveCopyingInfoCollisions_Error(t *testing.T) {
+	_, err := internal.ResolveCopyingInfoCollisions(makeCollidedCopyingInfos(), internal.CopyCollisionError)
+	assert.IsType(t, internal.CopyTargetCollisionError{}, err)
+	assert.Contains(t, err.Error(), "x")
+}
+
+func TestResolveCopyingInfoCollisions_Skip(t *testing.T) {
+	infos, err := internal.ResolveCopyingInfoCollisions(makeCollidedCopyingInfos(), internal.CopyCollisionSkip)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a/x": "x", "c/y": "y"}, getCopyingInfosMapping(infos))
+}
+
+func TestResolveCopyingInfoCollisions_Overwrite(t *testing.T) {
+	infos, err := internal.ResolveCopyingInfoCollisions(makeCollidedCopyingInfos(), internal.CopyCollisionOverwrite)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"b/x": "x", "c/y": "y"}, getCopyingInfosMapping(infos))
+}
+
+func TestResolveCopyingInfoCollisions_Suffix(t *testing.T) {
+	infos, err := internal.ResolveCopyingInfoCollisions(makeCollidedCopyingInfos(), internal.CopyCollisionSuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a/x": "x", "b/x": "x_1", "c/y": "y"}, getCopyingInfosMapping(infos))
+}
+
+func TestResolveCopyingInfoCollisions_SuffixSkipsTakenNames(t *testing.T) {
+	var infos = makeCollidedCopyingInfos()
+	infos = append(infos, internal.CopyingInfo{Object: storage.NewLocalObject("d/x_1", time.Now(), 1), TargetName: "x_1"})
+
+	infos, err := internal.ResolveCopyingInfoCollisions(infos, internal.CopyCollisionSuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a/x": "x", "b/x": "x_2", "c/y": "y", "d/x_1": "x_1"}, getCopyingInfosMapping(infos))
+}
+
+func TestResolveCopyingInfoCollisions_UnknownPolicy(t *testing.T) {
+	_, err := internal.ResolveCopyingInfoCollisions(nil, "merge")
+	assert.IsType(t, internal.UnknownCopyCollisionPolicyError{}, err)
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"os"
+	"os/signal"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/tracelog"
+)
+
+const copyDrainedError = "not copied because copy was stopped by a signal"
+
+var errCopyDrained = errors.New("copy was stopped by a signal after in-flight objects were copied")
+
+// CopyWithDrain stops starting new copies when drain is closed, objects being copied are finished
+// and the rest are reported as not copied, so they can be copied later with --retry-failed
+func CopyWithDrain(drain <-chan struct{}) CopyOption {
+	return func(options *copyOptions) {
+		options.drain = drain
+	}
+}
+
+// DrainCopyOnSignal returns a channel for CopyWithDrain which is closed on the first of signals,
+// the second signal aborts the process immediately. Call stop to release signals after copy.
+func DrainCopyOnSignal(signals ...os.Signal) (drain <-chan struct{}, stop func()) {
+	received := make(chan os.Signal, 2)
+	drainChannel := make(chan struct{})
+	done := make(chan struct{})
+	signal.Notify(received, signals...)
+	go func() {
+		select {
+		case sig := <-received:
+			tracelog.WarningLogger.Printf("Got %v, finishing objects being copied, send it again to abort\n", sig)
+			close(drainChannel)
+		case <-done:
+			return
+		}
+		select {
+		case sig := <-received:
+			tracelog.ErrorLogger.Fatalf("Got %v again, aborting copy\n", sig)
+		case <-done:
+		}
+	}()
+	return drainChannel, func() {
+		signal.Stop(received)
+		close(done)
+	}
+}
+
+func isDrained(drain <-chan struct{}) bool {
+	select {
+	case <-drain:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,75 @@
+package internal_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+type blockingReadFolder struct {
+	storage.Folder
+	started chan string
+	release chan struct{}
+}
+
+func (folder blockingReadFolder) ReadObject(objectRelativePath string) (io.ReadCloser, error) {
+	folder.started <- objectRelativePath
+	<-folder.release
+	return folder.Folder.ReadObject(objectRelativePath)
+}
+
+func TestCopyWithDrain_FinishesInFlightAndSkipsPending(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	names := []string{"wal_005/a", "wal_005/b", "wal_005/c"}
+	for _, name := range names {
+		require.NoError(t, source.PutObject(name, strings.NewReader(name)))
+	}
+	from := blockingReadFolder{source, make(chan string, len(names)), make(chan struct{})}
+	to := testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	require.NoError(t, err)
+	drain := make(chan struct{})
+	var summary internal.CopySummary
+
+	type result struct {
+		isSuccess bool
+		err       error
+	}
+	done := make(chan result)
+	go func() {
+		// objects are as large as the whole budget, so they are copied one by one
+		isSuccess, err := internal.StartCopy(infos, internal.CopyWithInFlightBytesLimit(int64(len(names[0]))),
+			internal.CopyWithDrain(drain), internal.CopyWithSummary(&summary))
+		done <- result{isSuccess, err}
+	}()
+	inFlight := <-from.started
+	close(drain)
+	close(from.release)
+	copyResult := <-done
+
+	assert.Error(t, copyResult.err)
+	assert.False(t, copyResult.isSuccess)
+	assert.Equal(t, []string{"in_memory/" + inFlight}, getFolderObjectNames(t, to))
+	assert.Equal(t, 1, summary.Copied)
+	assert.Equal(t, 2, summary.Failed)
+}
+
+func TestCopyWithDrain_NotClosedCopiesEverything(t *testing.T) {
+	from := testtools.MakeDefaultInMemoryStorageFolder()
+	require.NoError(t, from.PutObject("wal_005/a", strings.NewReader("a")))
+	to := testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	require.NoError(t, err)
+
+	isSuccess, err := internal.StartCopy(infos, internal.CopyWithDrain(make(chan struct{})))
+
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	assert.Equal(t, []string{"in_memory/wal_005/a"}, getFolderObjectNames(t, to))
+}
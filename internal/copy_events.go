@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/wal-g/tracelog"
+)
+
+const (
+	CopyEventCopied     = "copied"
+	CopyEventFailed     = "failed"
+	CopyEventNotStarted = "not_started"
+)
+
+// CopyEvent is the result of copying one object, Duration is in seconds
+type CopyEvent struct {
+	Name     string  `json:"name"`
+	Target   string  `json:"target"`
+	Bytes    int64   `json:"bytes"`
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration"`
+}
+
+// CopyEventWriter writes a CopyEvent per object as a JSON line as soon as the object is done.
+// Nil CopyEventWriter writes nothing.
+type CopyEventWriter struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewCopyEventWriter(output io.Writer) *CopyEventWriter {
+	return &CopyEventWriter{encoder: json.NewEncoder(output)}
+}
+
+func (writer *CopyEventWriter) write(info CopyingInfo, status string, duration time.Duration, errText string) {
+	if writer == nil {
+		return
+	}
+	event := CopyEvent{Name: info.Object.GetName(), Target: info.TargetName, Status: status,
+		Error: errText, Duration: duration.Seconds()}
+	if status == CopyEventCopied {
+		event.Bytes = info.Object.GetSize()
+	}
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+	if encodeErr := writer.encoder.Encode(event); encodeErr != nil {
+		tracelog.WarningLogger.Printf("Failed to write copy event of '%s': %v\n", event.Name, encodeErr)
+	}
+}
+
+// CopyWithEvents writes a CopyEvent of every copied, failed or not started object to writer
+func CopyWithEvents(writer *CopyEventWriter) CopyOption {
+	return func(options *copyOptions) {
+		options.events = writer
+	}
+}
@@ -0,0 +1,63 @@
+package internal_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestCopyWithEvents_WritesJSONLineForEveryObject(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	names := []string{"wal_005/a", "wal_005/b", "wal_005/c", "wal_005/d"}
+	for _, name := range names {
+		require.NoError(t, source.PutObject(name, strings.NewReader(name)))
+	}
+	to := testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(failingReadFolder{source, "wal_005/b"}, to)
+	require.NoError(t, err)
+	var output bytes.Buffer
+
+	// objects are as large as the whole budget, so they are copied one by one and ones after b are not started
+	_, err = internal.StartCopy(infos, internal.CopyWithInFlightBytesLimit(int64(len(names[0]))),
+		internal.CopyWithEvents(internal.NewCopyEventWriter(&output)))
+	assert.Error(t, err)
+
+	events := make(map[string]internal.CopyEvent)
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		var event internal.CopyEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event), scanner.Text())
+		assert.NotContains(t, events, event.Name)
+		events[event.Name] = event
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, events, len(names))
+	copied := getFolderObjectNames(t, to)
+	for _, name := range names {
+		event := events[name]
+		assert.Equal(t, "in_memory/"+name, event.Target)
+		switch event.Status {
+		case internal.CopyEventCopied:
+			assert.Contains(t, copied, event.Target)
+			assert.Equal(t, int64(len(name)), event.Bytes)
+			assert.Empty(t, event.Error)
+		case internal.CopyEventFailed:
+			assert.Equal(t, "wal_005/b", name)
+			assert.Contains(t, event.Error, "read failed")
+		case internal.CopyEventNotStarted:
+			assert.NotContains(t, copied, event.Target)
+			assert.NotEmpty(t, event.Error)
+			assert.Zero(t, event.Duration)
+		default:
+			t.Errorf("unexpected status '%s' of '%s'", event.Status, name)
+		}
+	}
+	assert.Equal(t, internal.CopyEventFailed, events["wal_005/b"].Status)
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+)
+
+const copyAbortedError = "not copied because copy was aborted after an error"
+
+// CopyFailure describes an object which was not copied.
+// SourcePath is the full path of the object in source storage, so it doesn't depend on the folder it was listed from.
+type CopyFailure struct {
+	SourcePath string `json:"source_path"`
+	TargetName string `json:"target_name"`
+	Error      string `json:"error"`
+}
+
+func newCopyFailure(info CopyingInfo, err string) CopyFailure {
+	return CopyFailure{path.Join(info.From.GetPath(), info.Object.GetName()), info.TargetName, err}
+}
+
+type copyFailures struct {
+	mutex    sync.Mutex
+	failures []CopyFailure
+}
+
+func (failures *copyFailures) add(info CopyingInfo, err string) {
+	failures.mutex.Lock()
+	defer failures.mutex.Unlock()
+	failures.failures = append(failures.failures, newCopyFailure(info, err))
+}
+
+// WriteCopyFailureManifest writes failures as JSON to manifestPath
+func WriteCopyFailureManifest(manifestPath string, failures []CopyFailure) error {
+	file, err := os.Create(manifestPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create copy failure manifest '%s'", manifestPath)
+	}
+	defer file.Close()
+	return WriteAsJson(failures, file, true)
+}
+
+func ReadCopyFailureManifest(manifestPath string) ([]CopyFailure, error) {
+	manifestBytes, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read copy failure manifest '%s'", manifestPath)
+	}
+	var failures []CopyFailure
+	err = json.Unmarshal(manifestBytes, &failures)
+	return failures, errors.Wrapf(err, "failed to parse copy failure manifest '%s'", manifestPath)
+}
+
+// GetCopyingInfosToRetry builds infos copying objects listed in failures from folder from
+// to their original target names in folder to
+func GetCopyingInfosToRetry(from storage.Folder, to storage.Folder, failures []CopyFailure) ([]CopyingInfo, error) {
+	objects, err := storage.ListFolderRecursively(from)
+	if err != nil {
+		return nil, err
+	}
+	objectsByName := make(map[string]storage.Object, len(objects))
+	for _, object := range objects {
+		objectsByName[object.GetName()] = object
+	}
+	infos := make([]CopyingInfo, 0, len(failures))
+	for _, failure := range failures {
+		if !strings.HasPrefix(failure.SourcePath, from.GetPath()) {
+			return nil, errors.Errorf("failed object '%s' is not in source folder '%s'", failure.SourcePath, from.GetPath())
+		}
+		objectName := strings.TrimPrefix(strings.TrimPrefix(failure.SourcePath, from.GetPath()), "/")
+		object, ok := objectsByName[objectName]
+		if !ok {
+			return nil, errors.Errorf("failed object '%s' is not found in source folder", failure.SourcePath)
+		}
+		infos = append(infos, CopyingInfo{object, from, to, failure.TargetName})
+	}
+	return infos, nil
+}
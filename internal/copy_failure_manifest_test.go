@@ -0,0 +1,86 @@
+package internal_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+type failingReadFolder struct {
+	storage.Folder
+	failing string
+}
+
+func (folder failingReadFolder) ReadObject(objectRelativePath string) (io.ReadCloser, error) {
+	if objectRelativePath == folder.failing {
+		return nil, errors.New("read failed")
+	}
+	return folder.Folder.ReadObject(objectRelativePath)
+}
+
+func TestCopyFailureManifest_RetryCopiesExactlyFailedObjects(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	names := []string{"wal_005/a", "wal_005/b", "wal_005/c", "basebackups_005/d"}
+	for _, name := range names {
+		require.NoError(t, source.PutObject(name, strings.NewReader(name)))
+	}
+	to := testtools.MakeDefaultInMemoryStorageFolder()
+	dir, err := ioutil.TempDir("", "copy_manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	manifestPath := path.Join(dir, "failed.json")
+
+	infos, err := internal.GetAllCopyingInfo(failingReadFolder{source, "wal_005/b"}, to)
+	require.NoError(t, err)
+	isSuccess, err := internal.StartCopy(infos, internal.CopyWithFailureManifest(manifestPath))
+	assert.Error(t, err)
+	assert.False(t, isSuccess)
+
+	failures, err := internal.ReadCopyFailureManifest(manifestPath)
+	require.NoError(t, err)
+	failedTargets := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		failedTargets = append(failedTargets, failure.TargetName)
+	}
+	assert.Contains(t, failedTargets, "in_memory/wal_005/b")
+	copied := getFolderObjectNames(t, to)
+	for _, name := range names {
+		isFailed := containsString(failedTargets, "in_memory/"+name)
+		isCopied := containsString(copied, "in_memory/"+name)
+		assert.NotEqual(t, isFailed, isCopied, name)
+	}
+
+	retryInfos, err := internal.GetCopyingInfosToRetry(source, to, failures)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, failedTargets, getCopyingInfoTargetNames(retryInfos))
+	isSuccess, err = internal.StartCopy(retryInfos)
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	assert.Len(t, getFolderObjectNames(t, to), len(names))
+}
+
+func TestGetCopyingInfosToRetry_MissingSourceObject(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	failures := []internal.CopyFailure{{SourcePath: "in_memory/wal_005/a", TargetName: "in_memory/wal_005/a"}}
+	_, err := internal.GetCopyingInfosToRetry(source, testtools.MakeDefaultInMemoryStorageFolder(), failures)
+	assert.Error(t, err)
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
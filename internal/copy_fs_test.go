@@ -0,0 +1,68 @@
+package internal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/fs"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+)
+
+func makeTempFsFolder(t *testing.T) (*fs.Folder, func()) {
+	directory, err := ioutil.TempDir("", "copy_fs")
+	assert.NoError(t, err)
+	return fs.NewFolder(directory, ""), func() { os.RemoveAll(directory) }
+}
+
+func TestStartCopy_BetweenFsFolders(t *testing.T) {
+	from, removeFrom := makeTempFsFolder(t)
+	defer removeFrom()
+	to, removeTo := makeTempFsFolder(t)
+	defer removeTo()
+	var contents = map[string]string{
+		"basebackups_005/base_000000010000000000000002_backup_stop_sentinel.json": "{}",
+		"basebackups_005/base_000000010000000000000002/tar_partitions/part_1.tar": "tar",
+		"wal_005/000000010000000000000002.lz4":                                    "wal",
+	}
+	for name, content := range contents {
+		assert.NoError(t, from.PutObject(name, strings.NewReader(content)))
+	}
+
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, len(contents), len(infos))
+	isSuccess, err := internal.StartCopy(infos)
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+
+	for name, content := range contents {
+		reader, err := to.ReadObject(name)
+		assert.NoError(t, err)
+		copied, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.NoError(t, reader.Close())
+		assert.Equal(t, content, string(copied))
+	}
+	copiedObjects, err := storage.ListFolderRecursively(to)
+	assert.NoError(t, err)
+	assert.Equal(t, len(contents), len(copiedObjects))
+}
+
+func TestStartCopy_FromFsFolderMissingObject(t *testing.T) {
+	from, removeFrom := makeTempFsFolder(t)
+	defer removeFrom()
+	to, removeTo := makeTempFsFolder(t)
+	defer removeTo()
+	assert.NoError(t, from.PutObject("sub/object", strings.NewReader("content")))
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+	assert.NoError(t, from.DeleteObjects([]string{"sub/object"}))
+
+	isSuccess, err := internal.StartCopy(infos)
+	assert.IsType(t, storage.ObjectNotFoundError{}, err)
+	assert.False(t, isSuccess)
+}
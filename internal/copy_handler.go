@@ -1,82 +1,413 @@
 package internal
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"path"
+	"regexp"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/wal-g/storages/storage"
 	"github.com/wal-g/tracelog"
 	"github.com/wal-g/wal-g/utility"
 )
 
+// MinCopyNotFoundRetryWait and MaxCopyNotFoundRetryWait bound waits between reads of missing copy source
+var MinCopyNotFoundRetryWait = time.Second
+var MaxCopyNotFoundRetryWait = 10 * time.Second
+
 type CopyingInfo struct {
-	Object storage.Object
-	From   storage.Folder
-	To     storage.Folder
+	Object     storage.Object
+	From       storage.Folder
+	To         storage.Folder
+	TargetName string
+}
+
+// CopySettings holds parameters of copy command
+type CopySettings struct {
+	FromConfigFile string
+	ToConfigFile   string
+	BackupName     string
+	WithoutHistory bool
+	RenameRule     string
+	NoProgress     bool
+	ForceProgress  bool
+	ModifiedSince  time.Time
+	// NotFoundRetries is the number of rereads of source object which is not found,
+	// this helps with freshly written objects in eventually consistent storages
+	NotFoundRetries int
+	// CollisionPolicy is one of CopyCollisionPolicies
+	CollisionPolicy string
+	SuccessMarker   bool
+	// Repair copies only objects which are missing in destination or differ from source
+	Repair bool
+	// SizeOrder is CopyOrderLargestFirst, CopyOrderSmallestFirst or empty to keep listing order
+	SizeOrder string
+	// Sync copies only objects missing or changed in destination, see SyncFolder
+	Sync   bool
+	Delete bool
+	// Force allows copy of more objects than CopyMaxObjectsSetting
+	Force bool
+	// RampUp is the time during which copy concurrency grows to the full limit, see CopyWithRampUp
+	RampUp time.Duration
+	// FailureManifest is the file where objects which were not copied are written if copy fails
+	FailureManifest string
+	// RetryFailed is the failure manifest of previous run, only objects listed in it are copied
+	RetryFailed string
+	// Flatten puts all objects to the top level of destination, see FlattenCopyingInfos
+	Flatten bool
+	// NameRegex selects objects whose whole name matches it, see CompileCopyNameRegex
+	NameRegex *regexp.Regexp
+	// EventsPath is the file where a CopyEvent per object is written as JSON line, "-" means stdout
+	EventsPath string
+}
+
+type copyOptions struct {
+	progressBar *ProgressBar
+	copyLog     *CopyLog
+
+	inFlightBytes   int64
+	rampUpWindow    time.Duration
+	notFoundRetries int
+
+	successMarkerFolder storage.Folder
+	failureManifestPath string
+	summary             *CopySummary
+	drain               <-chan struct{}
+	events              *CopyEventWriter
+}
+
+type CopyOption func(*copyOptions)
+
+// CopyWithProgressBar reports copied objects and bytes to progressBar
+func CopyWithProgressBar(progressBar *ProgressBar) CopyOption {
+	return func(options *copyOptions) {
+		options.progressBar = progressBar
+	}
+}
+
+// CopyWithLog writes result of every copied object to copyLog
+func CopyWithLog(copyLog *CopyLog) CopyOption {
+	return func(options *copyOptions) {
+		options.copyLog = copyLog
+	}
+}
+
+// CopyWithInFlightBytesLimit bounds total size of objects copied at the same time
+func CopyWithInFlightBytesLimit(inFlightBytes int64) CopyOption {
+	return func(options *copyOptions) {
+		options.inFlightBytes = inFlightBytes
+	}
+}
+
+// CopyWithRampUp starts copying one object at a time
+// and raises the number of parallel jobs to the full limit during window, so copy doesn't start with a burst of requests
+func CopyWithRampUp(window time.Duration) CopyOption {
+	return func(options *copyOptions) {
+		options.rampUpWindow = window
+	}
+}
+
+// CopyWithNotFoundRetries rereads source objects which are not found up to retries times
+func CopyWithNotFoundRetries(retries int) CopyOption {
+	return func(options *copyOptions) {
+		options.notFoundRetries = retries
+	}
+}
+
+// CopyWithSuccessMarker writes CopySuccessMarkerName object to folder after all objects are copied.
+// Marker left by previous copy is removed before copying starts.
+func CopyWithSuccessMarker(folder storage.Folder) CopyOption {
+	return func(options *copyOptions) {
+		options.successMarkerFolder = folder
+	}
+}
+
+// CopyWithFailureManifest writes objects which were not copied to manifestPath if copy fails,
+// see GetCopyingInfosToRetry
+func CopyWithFailureManifest(manifestPath string) CopyOption {
+	return func(options *copyOptions) {
+		options.failureManifestPath = manifestPath
+	}
+}
+
+// CopyWithSummary fills summary with counts of copied and failed objects when copy ends
+func CopyWithSummary(summary *CopySummary) CopyOption {
+	return func(options *copyOptions) {
+		options.summary = summary
+	}
 }
 
 // HandleCopy copy specific or all backups from one storage to another
-func HandleCopy(fromConfigFile string, toConfigFile string, backupName string, withoutHistory bool) {
-	var from, fromError = ConfigureFolderFromConfig(fromConfigFile)
-	var to, toError = ConfigureFolderFromConfig(toConfigFile)
+func HandleCopy(settings CopySettings) {
+	var from, fromError = ConfigureFolderFromConfig(settings.FromConfigFile)
+	var to, toError = ConfigureFolderFromConfig(settings.ToConfigFile)
 	if fromError != nil || toError != nil {
 		return
 	}
-	infos, err := getCopyingInfoToCopy(backupName, from, to, withoutHistory)
+	var infos []CopyingInfo
+	var listedCount int
+	var syncPlan SyncPlan
+	maxObjects, err := getCopyMaxObjects()
+	tracelog.ErrorLogger.FatalOnError(err)
+	if settings.Sync {
+		syncPlan, err = PlanSync(from, to, settings.Delete)
+		tracelog.ErrorLogger.FatalOnError(err)
+		infos, listedCount = syncPlan.Infos, syncPlan.Listed
+		err = CheckSyncObjectLimit(syncPlan, maxObjects, settings.Force)
+		tracelog.ErrorLogger.FatalOnError(err)
+	} else {
+		infos, err = getCopyingInfoToCopy(settings, from, to)
+		tracelog.ErrorLogger.FatalOnError(err)
+		listedCount = len(infos)
+		infos, err = filterCopyingInfos(infos, settings)
+		tracelog.ErrorLogger.FatalOnError(err)
+		err = CheckCopyObjectLimit(infos, maxObjects, settings.Force)
+		tracelog.ErrorLogger.FatalOnError(err)
+	}
+	copyLog, err := configureCopyLog()
 	tracelog.ErrorLogger.FatalOnError(err)
-	isSuccess, err := StartCopy(infos)
+	defer copyLog.Close()
+	copyLog.Printf("Copying %d objects from '%s' to '%s'", len(infos), from.GetPath(), to.GetPath())
+	progressBar := NewProgressBar(os.Stdout, settings.NoProgress || settings.EventsPath == "-", settings.ForceProgress,
+		int64(len(infos)), getCopyingInfosSize(infos))
+	setters := []CopyOption{CopyWithProgressBar(progressBar), CopyWithLog(copyLog),
+		CopyWithNotFoundRetries(settings.NotFoundRetries), CopyWithRampUp(settings.RampUp)}
+	if settings.SuccessMarker {
+		setters = append(setters, CopyWithSuccessMarker(to))
+	}
+	if settings.FailureManifest != "" {
+		setters = append(setters, CopyWithFailureManifest(settings.FailureManifest))
+	}
+	summary := CopySummary{Skipped: listedCount - len(infos)}
+	setters = append(setters, CopyWithSummary(&summary))
+	drain, stopDrain := DrainCopyOnSignal(os.Interrupt, syscall.SIGTERM)
+	defer stopDrain()
+	setters = append(setters, CopyWithDrain(drain))
+	// the summary line would break JSON lines in stdout
+	summaryOutput := os.Stdout
+	if settings.EventsPath != "" {
+		eventsFile := os.Stdout
+		if settings.EventsPath != "-" {
+			eventsFile, err = os.Create(settings.EventsPath)
+			tracelog.ErrorLogger.FatalfOnError("Failed to create copy events file: %v", err)
+			defer utility.LoggedClose(eventsFile, "")
+		} else {
+			summaryOutput = os.Stderr
+		}
+		setters = append(setters, CopyWithEvents(NewCopyEventWriter(eventsFile)))
+	}
+	isSuccess, err := StartCopy(infos, setters...)
+	progressBar.Finish()
+	fmt.Fprintln(summaryOutput, summary)
+	copyLog.Printf("Summary: %s", summary)
+	if err != nil {
+		copyLog.Printf("Copy failed: %v", err)
+	}
 	tracelog.ErrorLogger.FatalOnError(err)
+	if settings.Sync {
+		err = syncPlan.DeleteExtraneous(to)
+		tracelog.ErrorLogger.FatalOnError(err)
+		copyLog.Printf("Sync finished")
+		tracelog.InfoLogger.Println("Success sync.")
+		return
+	}
+	copyLog.Printf("Copy finished")
 	if isSuccess {
 		tracelog.InfoLogger.Println("Success copy.")
 	}
 }
 
-func StartCopy(infos []CopyingInfo) (bool, error) {
-	var maxParallelJobsCount = 8 // TODO place for improvement
-	for i := 0; i < len(infos); i += maxParallelJobsCount {
-		errors := make(chan error)
-		wgDone := make(chan bool)
-
-		var lastIndex = utility.Min(i+maxParallelJobsCount, len(infos))
-		var infosToCopy = infos[i:lastIndex]
-		var wg sync.WaitGroup
-		for _, info := range infosToCopy {
-			wg.Add(1)
-			go copyObject(info, &wg, errors)
+// filterCopyingInfos applies filters, collision policy and order of settings to listed infos
+func filterCopyingInfos(infos []CopyingInfo, settings CopySettings) ([]CopyingInfo, error) {
+	protectedGlobs, err := getProtectedObjectGlobs()
+	if err != nil {
+		return nil, err
+	}
+	infos = FilterProtectedCopyingInfos(infos, protectedGlobs)
+	if !settings.ModifiedSince.IsZero() {
+		infos = FilterCopyingInfosModifiedSince(infos, settings.ModifiedSince)
+	}
+	if settings.NameRegex != nil {
+		infos = FilterCopyingInfosByNameRegex(infos, settings.NameRegex)
+	}
+	infos, err = ResolveCopyingInfoCollisions(infos, settings.CollisionPolicy)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Repair {
+		infos, err = FilterCopyingInfosToRepair(infos)
+		if err != nil {
+			return nil, err
 		}
-		wg.Wait()
-		close(wgDone)
+	}
+	if settings.SizeOrder != "" {
+		err = SortCopyingInfosBySize(infos, settings.SizeOrder)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
+}
 
-		select {
-		case <-wgDone:
-			break
-		case err := <-errors:
-			close(errors)
+func StartCopy(infos []CopyingInfo, setters ...CopyOption) (bool, error) {
+	options := copyOptions{inFlightBytes: DefaultCopyInFlightBytes}
+	for _, setter := range setters {
+		setter(&options)
+	}
+	startTime := time.Now()
+	if options.successMarkerFolder != nil {
+		err := removeCopySuccessMarker(options.successMarkerFolder)
+		if err != nil {
+			return false, err
+		}
+	}
+	failures, err := copyInfos(infos, options)
+	if options.summary != nil {
+		options.summary.fill(infos, failures, time.Since(startTime))
+	}
+	if err != nil {
+		if options.failureManifestPath != "" {
+			manifestErr := WriteCopyFailureManifest(options.failureManifestPath, failures)
+			if manifestErr != nil {
+				tracelog.ErrorLogger.Printf("%v", manifestErr)
+			} else {
+				tracelog.InfoLogger.Printf("%d objects were not copied, see '%s'", len(failures), options.failureManifestPath)
+			}
+		}
+		return false, err
+	}
+	if options.successMarkerFolder != nil {
+		err = putCopySuccessMarker(options.successMarkerFolder, newCopySuccessMarkerDto(infos, startTime))
+		if err != nil {
 			return false, err
 		}
 	}
 	return true, nil
 }
 
-func copyObject(info CopyingInfo, wg *sync.WaitGroup, errors chan error) {
-	defer wg.Done()
+// copyInfos stops starting new copies after the first error or drain and returns objects which were not copied
+func copyInfos(infos []CopyingInfo, options copyOptions) ([]CopyFailure, error) {
+	failures := &copyFailures{}
+	budget := newRampingCopyBudget(options.inFlightBytes, options.rampUpWindow)
+	defer budget.close()
+	var wg sync.WaitGroup
+	var firstError error
+	var errorMutex sync.Mutex
+	stopReason := func() string {
+		errorMutex.Lock()
+		defer errorMutex.Unlock()
+		if firstError != nil {
+			return copyAbortedError
+		}
+		if isDrained(options.drain) {
+			firstError = errCopyDrained
+			return copyDrainedError
+		}
+		return ""
+	}
+	for i, info := range infos {
+		cost := budget.acquire(info.Object.GetSize())
+		if reason := stopReason(); reason != "" {
+			budget.release(cost)
+			for _, notStarted := range infos[i:] {
+				failures.add(notStarted, reason)
+				options.events.write(notStarted, CopyEventNotStarted, 0, reason)
+			}
+			break
+		}
+		wg.Add(1)
+		go func(info CopyingInfo) {
+			defer wg.Done()
+			defer budget.release(cost)
+			objectStartTime := time.Now()
+			err := copyObject(info, options)
+			if err == nil {
+				options.events.write(info, CopyEventCopied, time.Since(objectStartTime), "")
+			} else {
+				options.events.write(info, CopyEventFailed, time.Since(objectStartTime), err.Error())
+				failures.add(info, err.Error())
+				errorMutex.Lock()
+				if firstError == nil {
+					firstError = err
+				}
+				errorMutex.Unlock()
+			}
+		}(info)
+	}
+	wg.Wait()
+	return failures.failures, firstError
+}
+
+func copyObject(info CopyingInfo, options copyOptions) error {
 	var objectName, from, to = info.Object.GetName(), info.From, info.To
-	var readCloser, err = from.ReadObject(objectName)
+	err := retryTruncatedRead(func() error {
+		readCloser, err := readCopySource(info, options.notFoundRetries)
+		if err != nil {
+			return err
+		}
+		defer readCloser.Close()
+		return to.PutObject(info.TargetName, options.progressBar.NewReader(readCloser))
+	})
 	if err != nil {
-		errors <- err
-		return
+		options.copyLog.LogFailed(info, err)
+		return err
+	}
+	options.progressBar.AddObject()
+	options.copyLog.LogCopied(info)
+	tracelog.InfoLogger.Printf("Copied '%s' from '%s' to '%s' as '%s'.", objectName, from.GetPath(), to.GetPath(), info.TargetName)
+	return nil
+}
+
+func readCopySource(info CopyingInfo, notFoundRetries int) (io.ReadCloser, error) {
+	retrier := newExponentialRetrier(MinCopyNotFoundRetryWait, MaxCopyNotFoundRetryWait)
+	for i := 0; ; i++ {
+		readCloser, err := readObjectOfSize(info.From, info.Object.GetName(), info.Object.GetSize())
+		if _, isNotFound := err.(storage.ObjectNotFoundError); !isNotFound || i >= notFoundRetries {
+			return readCloser, err
+		}
+		tracelog.WarningLogger.Printf("'%s' is not found, retrying (%d/%d)\n", info.Object.GetName(), i+1, notFoundRetries)
+		retrier.retry()
 	}
-	var filename = path.Join(from.GetPath(), objectName)
-	err = to.PutObject(filename, readCloser)
+}
+
+// getCopyingInfoToCopy lists objects selected by settings and applies their target names
+func getCopyingInfoToCopy(settings CopySettings, from storage.Folder, to storage.Folder) ([]CopyingInfo, error) {
+	if settings.RetryFailed != "" {
+		failures, err := ReadCopyFailureManifest(settings.RetryFailed)
+		if err != nil {
+			return nil, err
+		}
+		tracelog.InfoLogger.Printf("Retrying %d objects from '%s'", len(failures), settings.RetryFailed)
+		return GetCopyingInfosToRetry(from, to, failures)
+	}
+	infos, err := getCopyingInfoOfBackup(settings.BackupName, from, to, settings.WithoutHistory)
 	if err != nil {
-		errors <- err
-		return
+		return nil, err
+	}
+	if settings.Flatten {
+		FlattenCopyingInfos(infos)
 	}
-	tracelog.InfoLogger.Printf("Copied '%s' from '%s' to '%s'.", objectName, from.GetPath(), to.GetPath())
+	if settings.RenameRule != "" {
+		renameFunc, err := GetCopyRenameFunc(settings.RenameRule)
+		if err != nil {
+			return nil, err
+		}
+		RenameCopyingInfos(infos, renameFunc)
+		err = ValidateCopyingInfoTargetNames(infos)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return infos, nil
 }
 
-func getCopyingInfoToCopy(backupName string, from storage.Folder, to storage.Folder, withoutHistory bool) ([]CopyingInfo, error) {
+func getCopyingInfoOfBackup(backupName string, from storage.Folder, to storage.Folder, withoutHistory bool) ([]CopyingInfo, error) {
 	if backupName == "" {
 		tracelog.InfoLogger.Printf("Copy all backups and history.")
 		return GetAllCopyingInfo(from, to)
@@ -142,8 +473,66 @@ func BuildCopyingInfos(from storage.Folder, to storage.Folder, objects []storage
 	condition func(storage.Object) bool) (infos []CopyingInfo) {
 	for _, object := range objects {
 		if condition(object) {
-			infos = append(infos, CopyingInfo{object, from, to})
+			infos = append(infos, CopyingInfo{object, from, to, path.Join(from.GetPath(), object.GetName())})
 		}
 	}
 	return
 }
+
+func getCopyingInfosSize(infos []CopyingInfo) (size int64) {
+	for _, info := range infos {
+		size += info.Object.GetSize()
+	}
+	return
+}
+
+// FilterCopyingInfosModifiedSince skips objects which were modified before since.
+// Objects modified exactly at since are kept, as ListFolderSince does with a time marker,
+// because objects put in the same second as the previous sync may have been missed by it.
+func FilterCopyingInfosModifiedSince(infos []CopyingInfo, since time.Time) []CopyingInfo {
+	filtered := make([]CopyingInfo, 0, len(infos))
+	for _, info := range infos {
+		if info.Object.GetLastModified().Before(since) {
+			tracelog.DebugLogger.Printf("Skipping '%s': not modified since %s", info.Object.GetName(), since)
+			continue
+		}
+		filtered = append(filtered, info)
+	}
+	tracelog.InfoLogger.Printf("%d of %d objects were modified since %s", len(filtered), len(infos), since)
+	return filtered
+}
+
+// CompileCopyNameRegex compiles pattern anchored at both ends, so it has to match the whole object name
+func CompileCopyNameRegex(pattern string) (*regexp.Regexp, error) {
+	regex, err := regexp.Compile("^(?:" + pattern + ")$")
+	return regex, errors.Wrapf(err, "invalid name regex '%s'", pattern)
+}
+
+// FilterCopyingInfosByNameRegex skips objects whose names relative to source folder don't match regex
+func FilterCopyingInfosByNameRegex(infos []CopyingInfo, regex *regexp.Regexp) []CopyingInfo {
+	filtered := make([]CopyingInfo, 0, len(infos))
+	for _, info := range infos {
+		if !regex.MatchString(info.Object.GetName()) {
+			tracelog.DebugLogger.Printf("Skipping '%s': name doesn't match %s", info.Object.GetName(), regex)
+			continue
+		}
+		filtered = append(filtered, info)
+	}
+	tracelog.InfoLogger.Printf("%d of %d objects match name regex %s", len(filtered), len(infos), regex)
+	return filtered
+}
+
+// FlattenCopyingInfos strips subfolders from target names, so objects are copied to the top level
+// of destination. Objects with the same name in different subfolders collide, see ResolveCopyingInfoCollisions.
+func FlattenCopyingInfos(infos []CopyingInfo) {
+	for i := range infos {
+		infos[i].TargetName = path.Join(infos[i].From.GetPath(), path.Base(infos[i].Object.GetName()))
+	}
+}
+
+// RenameCopyingInfos applies renameFunc to target names of infos
+func RenameCopyingInfos(infos []CopyingInfo, renameFunc RenameFunc) {
+	for i := range infos {
+		infos[i].TargetName = renameFunc(infos[i].TargetName)
+	}
+}
@@ -1,9 +1,13 @@
 package internal_test
 
 import (
+	"bytes"
+	"io"
 	"path"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/wal-g/storages/storage"
@@ -136,3 +140,199 @@ func TestBuildCopyingInfos_WhenComplexCondition(t *testing.T) {
 		assert.True(t, condition(info.Object))
 	}
 }
+
+func TestFilterCopyingInfosModifiedSince(t *testing.T) {
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	var lastSync = time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+	objects := []storage.Object{
+		storage.NewLocalObject("unchanged", lastSync.Add(-time.Hour), 1),
+		storage.NewLocalObject("synced_exactly", lastSync, 1),
+		storage.NewLocalObject("modified", lastSync.Add(time.Hour), 1),
+	}
+	var infos = internal.BuildCopyingInfos(from, to, objects, func(object storage.Object) bool { return true })
+
+	var filtered = internal.FilterCopyingInfosModifiedSince(infos, lastSync)
+	assert.Equal(t, []string{"synced_exactly", "modified"}, getCopyingInfosNames(filtered))
+}
+
+func TestFilterCopyingInfosModifiedSince_BoundAgreesWithListFolderSince(t *testing.T) {
+	var lastSync = time.Date(2020, 8, 1, 0, 0, 0, 0, time.UTC)
+	var from = sameTimeFolder{testtools.MakeDefaultInMemoryStorageFolder(), lastSync}
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, from.PutObject("synced_exactly", &bytes.Buffer{}))
+
+	listed, _, err := internal.ListFolderSince(from, lastSync.Format(time.RFC3339Nano))
+	assert.NoError(t, err)
+	var infos = internal.BuildCopyingInfos(from, to, listed, func(object storage.Object) bool { return true })
+	var filtered = internal.FilterCopyingInfosModifiedSince(infos, lastSync)
+	assert.Equal(t, []string{"synced_exactly"}, getObjectNames(listed))
+	assert.Equal(t, []string{"synced_exactly"}, getCopyingInfosNames(filtered))
+}
+
+func TestFilterCopyingInfosModifiedSince_CopiesWhenModified(t *testing.T) {
+	var from = testtools.CreateMockStorageFolderWithPermanentBackups(t)
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	var filtered = internal.FilterCopyingInfosModifiedSince(infos, time.Time{})
+	assert.Equal(t, len(infos), len(filtered))
+	isSuccess, err := internal.StartCopy(filtered)
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	for _, info := range filtered {
+		exists, err := to.Exists(info.TargetName)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+type inFlightTrackingFolder struct {
+	storage.Folder
+	mutex       sync.Mutex
+	inFlight    int64
+	maxInFlight int64
+}
+
+func (folder *inFlightTrackingFolder) PutObject(name string, content io.Reader) error {
+	var buffer bytes.Buffer
+	size, err := buffer.ReadFrom(content)
+	if err != nil {
+		return err
+	}
+	folder.mutex.Lock()
+	folder.inFlight += size
+	if folder.inFlight > folder.maxInFlight {
+		folder.maxInFlight = folder.inFlight
+	}
+	folder.mutex.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	folder.mutex.Lock()
+	folder.inFlight -= size
+	folder.mutex.Unlock()
+	return folder.Folder.PutObject(name, &buffer)
+}
+
+func TestStartCopy_BoundsInFlightBytes(t *testing.T) {
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	for i, size := range []int{40, 70, 10, 100, 30, 60, 20, 90} {
+		err := from.PutObject(string(rune('a'+i)), bytes.NewReader(make([]byte, size)))
+		assert.NoError(t, err)
+	}
+	var to = &inFlightTrackingFolder{Folder: testtools.MakeDefaultInMemoryStorageFolder()}
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	isSuccess, err := internal.StartCopy(infos, internal.CopyWithInFlightBytesLimit(100))
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	assert.True(t, to.maxInFlight <= 100, "in flight bytes %d exceed the limit", to.maxInFlight)
+	assert.True(t, to.maxInFlight > 0)
+	for _, info := range infos {
+		exists, err := to.Exists(info.TargetName)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+func TestStartCopy_ReturnsErrorWhenObjectIsMissing(t *testing.T) {
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	var objects = []storage.Object{storage.NewLocalObject("missing", time.Now(), 10)}
+	var infos = internal.BuildCopyingInfos(from, to, objects, func(object storage.Object) bool { return true })
+
+	isSuccess, err := internal.StartCopy(infos)
+	assert.Error(t, err)
+	assert.False(t, isSuccess)
+}
+
+type eventuallyConsistentFolder struct {
+	storage.Folder
+	missingReads int
+	reads        int
+}
+
+func (folder *eventuallyConsistentFolder) ReadObject(objectRelativePath string) (io.ReadCloser, error) {
+	folder.reads++
+	if folder.reads <= folder.missingReads {
+		return nil, storage.NewObjectNotFoundError(objectRelativePath)
+	}
+	return folder.Folder.ReadObject(objectRelativePath)
+}
+
+func copyFromEventuallyConsistentFolder(t *testing.T, missingReads int) (*eventuallyConsistentFolder, bool, error) {
+	minWait, maxWait := internal.MinCopyNotFoundRetryWait, internal.MaxCopyNotFoundRetryWait
+	internal.MinCopyNotFoundRetryWait, internal.MaxCopyNotFoundRetryWait = time.Millisecond, time.Millisecond
+	defer func() {
+		internal.MinCopyNotFoundRetryWait, internal.MaxCopyNotFoundRetryWait = minWait, maxWait
+	}()
+
+	var from = &eventuallyConsistentFolder{Folder: testtools.MakeDefaultInMemoryStorageFolder(), missingReads: missingReads}
+	assert.NoError(t, from.PutObject("fresh", strings.NewReader("content")))
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	isSuccess, err := internal.StartCopy(infos, internal.CopyWithNotFoundRetries(2))
+	return from, isSuccess, err
+}
+
+func TestStartCopy_RetriesNotFoundSource(t *testing.T) {
+	from, isSuccess, err := copyFromEventuallyConsistentFolder(t, 1)
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	assert.Equal(t, 2, from.reads)
+}
+
+func TestStartCopy_FailsWhenSourceIsNotFoundAfterRetries(t *testing.T) {
+	from, isSuccess, err := copyFromEventuallyConsistentFolder(t, 10)
+	assert.IsType(t, storage.ObjectNotFoundError{}, err)
+	assert.False(t, isSuccess)
+	assert.Equal(t, 3, from.reads)
+}
+
+func TestFilterCopyingInfosByNameRegex(t *testing.T) {
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	objects := []storage.Object{
+		storage.NewLocalObject("basebackups_005/base_000000010000000000000002_backup_stop_sentinel.json", time.Time{}, 1),
+		storage.NewLocalObject("basebackups_005/base_000000010000000000000002/metadata.json", time.Time{}, 1),
+		storage.NewLocalObject("basebackups_005/base_000000010000000000000004_backup_stop_sentinel.json", time.Time{}, 1),
+		storage.NewLocalObject("wal_005/000000010000000000000002.lz4", time.Time{}, 1),
+	}
+	var infos = internal.BuildCopyingInfos(from, to, objects, func(object storage.Object) bool { return true })
+	regex, err := internal.CompileCopyNameRegex(`basebackups_005/base_0+10+2.*`)
+	assert.NoError(t, err)
+
+	var filtered = internal.FilterCopyingInfosByNameRegex(infos, regex)
+	assert.Equal(t, []string{
+		"basebackups_005/base_000000010000000000000002_backup_stop_sentinel.json",
+		"basebackups_005/base_000000010000000000000002/metadata.json",
+	}, getCopyingInfosNames(filtered))
+}
+
+func TestFilterCopyingInfosByNameRegex_MatchesWholeName(t *testing.T) {
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	objects := []storage.Object{
+		storage.NewLocalObject("wal_005/000000010000000000000002.lz4", time.Time{}, 1),
+		storage.NewLocalObject("wal_005/000000010000000000000002.lz4.partial", time.Time{}, 1),
+	}
+	var infos = internal.BuildCopyingInfos(from, to, objects, func(object storage.Object) bool { return true })
+	regex, err := internal.CompileCopyNameRegex(`wal_005/[0-9A-F]{24}\.lz4`)
+	assert.NoError(t, err)
+
+	var filtered = internal.FilterCopyingInfosByNameRegex(infos, regex)
+	assert.Equal(t, []string{"wal_005/000000010000000000000002.lz4"}, getCopyingInfosNames(filtered))
+	regex, err = internal.CompileCopyNameRegex(`000000010000000000000002`)
+	assert.NoError(t, err)
+	assert.Empty(t, internal.FilterCopyingInfosByNameRegex(infos, regex))
+}
+
+func TestCompileCopyNameRegex_InvalidPattern(t *testing.T) {
+	_, err := internal.CompileCopyNameRegex(`base_(`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wal-g/tracelog"
+)
+
+const copyLogTimeFormat = "20060102T150405Z"
+
+// CopyLog writes per-object results of a copy run to a dedicated file,
+// so long migrations can be examined after the run independently of stdout.
+// Nil CopyLog logs nothing.
+type CopyLog struct {
+	file   *os.File
+	logger *log.Logger
+}
+
+// NewCopyLog creates timestamped log file under directory
+func NewCopyLog(directory string, startTime time.Time) (*CopyLog, error) {
+	err := os.MkdirAll(directory, 0755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create copy log directory '%s'", directory)
+	}
+	logPath := filepath.Join(directory, fmt.Sprintf("copy_%s.log", startTime.UTC().Format(copyLogTimeFormat)))
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create copy log '%s'", logPath)
+	}
+	return &CopyLog{file, log.New(file, "", log.LstdFlags|log.LUTC)}, nil
+}
+
+// configureCopyLog returns copy log in WALG_COPY_LOG_DIRECTORY or nil if it is not set
+func configureCopyLog() (*CopyLog, error) {
+	directory := viper.GetString(CopyLogDirectorySetting)
+	if directory == "" {
+		return nil, nil
+	}
+	copyLog, err := NewCopyLog(directory, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	tracelog.InfoLogger.Printf("Writing copy log to '%s'", copyLog.Path())
+	return copyLog, nil
+}
+
+func (copyLog *CopyLog) Path() string {
+	if copyLog == nil {
+		return ""
+	}
+	return copyLog.file.Name()
+}
+
+func (copyLog *CopyLog) LogCopied(info CopyingInfo) {
+	if copyLog == nil {
+		return
+	}
+	copyLog.logger.Printf("OK '%s' -> '%s' (%d bytes)", info.Object.GetName(), info.TargetName, info.Object.GetSize())
+}
+
+func (copyLog *CopyLog) LogFailed(info CopyingInfo, err error) {
+	if copyLog == nil {
+		return
+	}
+	copyLog.logger.Printf("FAILED '%s' -> '%s': %v", info.Object.GetName(), info.TargetName, err)
+}
+
+func (copyLog *CopyLog) Printf(format string, v ...interface{}) {
+	if copyLog == nil {
+		return
+	}
+	copyLog.logger.Printf(format, v...)
+}
+
+func (copyLog *CopyLog) Close() error {
+	if copyLog == nil {
+		return nil
+	}
+	return copyLog.file.Close()
+}
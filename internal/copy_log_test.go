@@ -0,0 +1,47 @@
+package internal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestCopyLog_ContainsCopiedObjects(t *testing.T) {
+	directory, err := ioutil.TempDir("", "copy_log")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	var from = testtools.CreateMockStorageFolderWithPermanentBackups(t)
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	startTime := time.Date(2020, 9, 1, 12, 30, 0, 0, time.UTC)
+	copyLog, err := internal.NewCopyLog(filepath.Join(directory, "logs"), startTime)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(directory, "logs", "copy_20200901T123000Z.log"), copyLog.Path())
+
+	isSuccess, err := internal.StartCopy(infos, internal.CopyWithLog(copyLog))
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	assert.NoError(t, copyLog.Close())
+
+	content, err := ioutil.ReadFile(copyLog.Path())
+	assert.NoError(t, err)
+	for _, info := range infos {
+		assert.Contains(t, string(content), "OK '"+info.Object.GetName()+"'")
+	}
+}
+
+func TestCopyLog_NilLogsNothing(t *testing.T) {
+	var copyLog *internal.CopyLog
+	copyLog.Printf("nothing")
+	assert.Empty(t, copyLog.Path())
+	assert.NoError(t, copyLog.Close())
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/tracelog"
+)
+
+type CopyObjectLimitExceededError struct {
+	error
+}
+
+func newCopyObjectLimitExceededError(count, limit int, action string) CopyObjectLimitExceededError {
+	return CopyObjectLimitExceededError{errors.Errorf(
+		"%d objects are going to be %s, which is more than %s=%d: check filters or use --force",
+		count, action, CopyMaxObjectsSetting, limit)}
+}
+
+func (err CopyObjectLimitExceededError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// getCopyMaxObjects returns limit of objects copied without --force, zero means no limit
+func getCopyMaxObjects() (int, error) {
+	value, ok := GetSetting(CopyMaxObjectsSetting)
+	if !ok {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, errors.Errorf("%s should be a non-negative integer, but is '%s'", CopyMaxObjectsSetting, value)
+	}
+	return limit, nil
+}
+
+// CheckCopyObjectLimit refuses copy of more than limit objects unless it is forced,
+// so that a copy with a missing filter doesn't move the whole storage
+func CheckCopyObjectLimit(infos []CopyingInfo, limit int, force bool) error {
+	return checkObjectLimit(len(infos), limit, force, "copied")
+}
+
+// CheckSyncObjectLimit is CheckCopyObjectLimit for sync, where deleted objects count too
+func CheckSyncObjectLimit(plan SyncPlan, limit int, force bool) error {
+	return checkObjectLimit(len(plan.Infos)+len(plan.Extraneous), limit, force, "copied or deleted")
+}
+
+func checkObjectLimit(count, limit int, force bool, action string) error {
+	if limit == 0 || count <= limit {
+		return nil
+	}
+	if force {
+		tracelog.WarningLogger.Printf("%d objects are going to be %s, which is more than %s=%d, since copy is forced\n",
+			count, action, CopyMaxObjectsSetting, limit)
+		return nil
+	}
+	return newCopyObjectLimitExceededError(count, limit, action)
+}
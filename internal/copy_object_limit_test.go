@@ -0,0 +1,25 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+)
+
+func TestCheckCopyObjectLimit(t *testing.T) {
+	infos := makeSizedCopyingInfos(t, map[string]int{"a": 1, "b": 1, "c": 1})
+
+	assert.NoError(t, internal.CheckCopyObjectLimit(infos, 0, false))
+	assert.NoError(t, internal.CheckCopyObjectLimit(infos, 3, false))
+	assert.IsType(t, internal.CopyObjectLimitExceededError{}, internal.CheckCopyObjectLimit(infos, 2, false))
+	assert.NoError(t, internal.CheckCopyObjectLimit(infos, 2, true))
+}
+
+func TestValidateSettings_InvalidCopyMaxObjects(t *testing.T) {
+	withSettings(t, map[string]string{internal.CopyMaxObjectsSetting: "-1"}, func() {
+		err := internal.ValidateSettings()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), internal.CopyMaxObjectsSetting)
+	})
+}
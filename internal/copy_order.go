@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/tracelog"
+)
+
+// Orders of copied objects by size
+const (
+	CopyOrderLargestFirst  = "largest-first"
+	CopyOrderSmallestFirst = "smallest-first"
+)
+
+type UnknownCopyOrderError struct {
+	error
+}
+
+func newUnknownCopyOrderError(order string) UnknownCopyOrderError {
+	return UnknownCopyOrderError{errors.Errorf("unknown copy order '%s', expected '%s' or '%s'",
+		order, CopyOrderLargestFirst, CopyOrderSmallestFirst)}
+}
+
+func (err UnknownCopyOrderError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// SortCopyingInfosBySize orders infos by object size. Starting with the largest objects
+// keeps a huge object from being the last one copied while other workers are idle.
+func SortCopyingInfosBySize(infos []CopyingInfo, order string) error {
+	var less func(i, j int) bool
+	switch order {
+	case CopyOrderLargestFirst:
+		less = func(i, j int) bool { return infos[i].Object.GetSize() > infos[j].Object.GetSize() }
+	case CopyOrderSmallestFirst:
+		less = func(i, j int) bool { return infos[i].Object.GetSize() < infos[j].Object.GetSize() }
+	default:
+		return newUnknownCopyOrderError(order)
+	}
+	sort.SliceStable(infos, less)
+	return nil
+}
@@ -0,0 +1,56 @@
+package internal_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func makeSizedCopyingInfos(t *testing.T, sizes map[string]int) []internal.CopyingInfo {
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	for name, size := range sizes {
+		assert.NoError(t, from.PutObject(name, bytes.NewReader(make([]byte, size))))
+	}
+	infos, err := internal.GetAllCopyingInfo(from, testtools.MakeDefaultInMemoryStorageFolder())
+	assert.NoError(t, err)
+	return infos
+}
+
+func getCopyingInfosNames(infos []internal.CopyingInfo) []string {
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Object.GetName())
+	}
+	return names
+}
+
+func TestSortCopyingInfosBySize_LargestFirst(t *testing.T) {
+	infos := makeSizedCopyingInfos(t, map[string]int{"small": 1, "huge": 1000, "medium": 10})
+
+	assert.NoError(t, internal.SortCopyingInfosBySize(infos, internal.CopyOrderLargestFirst))
+	assert.Equal(t, []string{"huge", "medium", "small"}, getCopyingInfosNames(infos))
+
+	isSuccess, err := internal.StartCopy(infos)
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	for _, info := range infos {
+		exists, err := info.To.Exists(info.TargetName)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+func TestSortCopyingInfosBySize_SmallestFirst(t *testing.T) {
+	infos := makeSizedCopyingInfos(t, map[string]int{"small": 1, "huge": 1000, "medium": 10})
+
+	assert.NoError(t, internal.SortCopyingInfosBySize(infos, internal.CopyOrderSmallestFirst))
+	assert.Equal(t, []string{"small", "medium", "huge"}, getCopyingInfosNames(infos))
+}
+
+func TestSortCopyingInfosBySize_UnknownOrder(t *testing.T) {
+	err := internal.SortCopyingInfosBySize(nil, "random")
+	assert.IsType(t, internal.UnknownCopyOrderError{}, err)
+}
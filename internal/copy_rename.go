@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wal-g/tracelog"
+)
+
+// RenameFunc maps object name in source storage to object name in destination storage
+type RenameFunc func(name string) string
+
+// CopyRenameRule describes named rename rule which can be referenced by copy command.
+// Exactly one of Prefix, Regex or DropSegment should be set.
+type CopyRenameRule struct {
+	Prefix      string `json:"prefix" mapstructure:"prefix"`
+	Regex       string `json:"regex" mapstructure:"regex"`
+	DropSegment string `json:"drop_segment" mapstructure:"drop_segment"`
+	Replacement string `json:"replacement" mapstructure:"replacement"`
+}
+
+type UnknownCopyRenameRuleError struct {
+	error
+}
+
+func newUnknownCopyRenameRuleError(ruleName string) UnknownCopyRenameRuleError {
+	return UnknownCopyRenameRuleError{errors.Errorf("Rename rule '%s' is not defined in %s", ruleName, CopyRenameRulesSetting)}
+}
+
+func (err UnknownCopyRenameRuleError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// NewPrefixRenameFunc replaces oldPrefix with newPrefix, names without oldPrefix are left as is
+func NewPrefixRenameFunc(oldPrefix, newPrefix string) RenameFunc {
+	return func(name string) string {
+		if !strings.HasPrefix(name, oldPrefix) {
+			return name
+		}
+		return newPrefix + strings.TrimPrefix(name, oldPrefix)
+	}
+}
+
+// NewRegexRenameFunc replaces every match of pattern with replacement, see regexp.ReplaceAllString
+func NewRegexRenameFunc(pattern, replacement string) (RenameFunc, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid rename regex '%s'", pattern)
+	}
+	return func(name string) string {
+		return re.ReplaceAllString(name, replacement)
+	}, nil
+}
+
+// NewDropSegmentRenameFunc removes every path element equal to segment,
+// e.g. dropping "wal_005" moves "prod/wal_005/file" to "prod/file"
+func NewDropSegmentRenameFunc(segment string) RenameFunc {
+	return func(name string) string {
+		elements := strings.Split(name, "/")
+		kept := make([]string, 0, len(elements))
+		for _, element := range elements {
+			if element != segment {
+				kept = append(kept, element)
+			}
+		}
+		return strings.Join(kept, "/")
+	}
+}
+
+func (rule CopyRenameRule) toRenameFunc() (RenameFunc, error) {
+	setCount := 0
+	for _, field := range []string{rule.Prefix, rule.Regex, rule.DropSegment} {
+		if field != "" {
+			setCount++
+		}
+	}
+	if setCount != 1 {
+		return nil, errors.New("exactly one of 'prefix', 'regex' or 'drop_segment' must be set in rename rule")
+	}
+	switch {
+	case rule.Prefix != "":
+		return NewPrefixRenameFunc(rule.Prefix, rule.Replacement), nil
+	case rule.DropSegment != "":
+		return NewDropSegmentRenameFunc(rule.DropSegment), nil
+	}
+	return NewRegexRenameFunc(rule.Regex, rule.Replacement)
+}
+
+func getCopyRenameRules() (map[string]CopyRenameRule, error) {
+	rules := make(map[string]CopyRenameRule)
+	if !viper.IsSet(CopyRenameRulesSetting) {
+		return rules, nil
+	}
+	// Rules from ENV or flags come as a JSON string, rules from config file come as a map
+	if rawRules, ok := viper.Get(CopyRenameRulesSetting).(string); ok {
+		err := json.Unmarshal([]byte(rawRules), &rules)
+		if err != nil {
+			return nil, newUnmarshallingError(CopyRenameRulesSetting, err)
+		}
+		return rules, nil
+	}
+	err := viper.UnmarshalKey(CopyRenameRulesSetting, &rules)
+	if err != nil {
+		return nil, newUnmarshallingError(CopyRenameRulesSetting, err)
+	}
+	return rules, nil
+}
+
+// GetCopyRenameFunc resolves rename rule configured in WALG_COPY_RENAME_RULES by its name
+func GetCopyRenameFunc(ruleName string) (RenameFunc, error) {
+	rules, err := getCopyRenameRules()
+	if err != nil {
+		return nil, err
+	}
+	rule, ok := rules[ruleName]
+	if !ok {
+		return nil, newUnknownCopyRenameRuleError(ruleName)
+	}
+	renameFunc, err := rule.toRenameFunc()
+	return renameFunc, errors.Wrapf(err, "invalid rename rule '%s'", ruleName)
+}
+
+type InvalidCopyTargetNameError struct {
+	error
+}
+
+func newInvalidCopyTargetNameError(objectName, targetName string) InvalidCopyTargetNameError {
+	return InvalidCopyTargetNameError{errors.Errorf("'%s' is renamed to invalid name '%s'", objectName, targetName)}
+}
+
+func (err InvalidCopyTargetNameError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// ValidateCopyingInfoTargetNames checks that renaming left every target name a relative path inside the destination
+func ValidateCopyingInfoTargetNames(infos []CopyingInfo) error {
+	for _, info := range infos {
+		targetName := strings.TrimPrefix(info.TargetName, "/")
+		cleaned := path.Clean(targetName)
+		if targetName == "" || strings.HasSuffix(targetName, "/") || cleaned == "." ||
+			cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return newInvalidCopyTargetNameError(info.Object.GetName(), info.TargetName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package internal_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestGetCopyRenameFunc_PrefixRuleFromConfig(t *testing.T) {
+	viper.Set(internal.CopyRenameRulesSetting, map[string]interface{}{
+		"prod-to-staging": map[string]interface{}{"prefix": "prod/", "replacement": "staging/"},
+	})
+	defer viper.Set(internal.CopyRenameRulesSetting, nil)
+
+	renameFunc, err := internal.GetCopyRenameFunc("prod-to-staging")
+	assert.NoError(t, err)
+	assert.Equal(t, "staging/basebackups_005/base_1", renameFunc("prod/basebackups_005/base_1"))
+	assert.Equal(t, "other/basebackups_005/base_1", renameFunc("other/basebackups_005/base_1"))
+}
+
+func TestGetCopyRenameFunc_RegexRuleFromEnvString(t *testing.T) {
+	viper.Set(internal.CopyRenameRulesSetting, `{"wal-to-archive": {"regex": "^(.*)/wal_005/", "replacement": "$1/archive/"}}`)
+	defer viper.Set(internal.CopyRenameRulesSetting, nil)
+
+	renameFunc, err := internal.GetCopyRenameFunc("wal-to-archive")
+	assert.NoError(t, err)
+	assert.Equal(t, "prod/archive/000000010000000000000001", renameFunc("prod/wal_005/000000010000000000000001"))
+}
+
+func TestGetCopyRenameFunc_UnknownRule(t *testing.T) {
+	viper.Set(internal.CopyRenameRulesSetting, map[string]interface{}{})
+	defer viper.Set(internal.CopyRenameRulesSetting, nil)
+
+	_, err := internal.GetCopyRenameFunc("prod-to-staging")
+	assert.Error(t, err)
+	assert.IsType(t, internal.UnknownCopyRenameRuleError{}, err)
+}
+
+func TestGetCopyRenameFunc_AmbiguousRule(t *testing.T) {
+	viper.Set(internal.CopyRenameRulesSetting, map[string]interface{}{
+		"broken": map[string]interface{}{"prefix": "prod/", "regex": "prod", "replacement": "staging/"},
+	})
+	defer viper.Set(internal.CopyRenameRulesSetting, nil)
+
+	_, err := internal.GetCopyRenameFunc("broken")
+	assert.Error(t, err)
+}
+
+func TestRenameCopyingInfos(t *testing.T) {
+	var from = testtools.CreateMockStorageFolder()
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, infos)
+
+	internal.RenameCopyingInfos(infos, internal.NewPrefixRenameFunc(from.GetPath(), "staging/"))
+	for _, info := range infos {
+		assert.Equal(t, "staging/"+info.Object.GetName(), info.TargetName)
+	}
+}
+
+func TestGetCopyRenameFunc_DropSegmentRule(t *testing.T) {
+	viper.Set(internal.CopyRenameRulesSetting, `{"flatten-wal": {"drop_segment": "wal_005"}}`)
+	defer viper.Set(internal.CopyRenameRulesSetting, nil)
+
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, from.PutObject("wal_005/000000010000000000000001.lz4", &bytes.Buffer{}))
+	assert.NoError(t, from.PutObject("wal_005/archive/000000010000000000000002.lz4", &bytes.Buffer{}))
+	assert.NoError(t, from.PutObject("basebackups_005/base_1/metadata.json", &bytes.Buffer{}))
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	renameFunc, err := internal.GetCopyRenameFunc("flatten-wal")
+	assert.NoError(t, err)
+	internal.RenameCopyingInfos(infos, renameFunc)
+	assert.NoError(t, internal.ValidateCopyingInfoTargetNames(infos))
+	_, err = internal.StartCopy(infos)
+	assert.NoError(t, err)
+
+	for _, name := range []string{"000000010000000000000001.lz4", "archive/000000010000000000000002.lz4",
+		"basebackups_005/base_1/metadata.json"} {
+		exists, err := to.Exists(from.GetPath() + name)
+		assert.NoError(t, err)
+		assert.True(t, exists, name)
+	}
+	exists, err := to.Exists(from.GetPath() + "wal_005/000000010000000000000001.lz4")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestValidateCopyingInfoTargetNames_InvalidNames(t *testing.T) {
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, from.PutObject("wal_005/000000010000000000000001.lz4", &bytes.Buffer{}))
+	for _, renameFunc := range []internal.RenameFunc{
+		func(string) string { return "" },
+		func(string) string { return "../outside" },
+		func(string) string { return "prod/../../outside" },
+		func(string) string { return "prod/" },
+	} {
+		infos, err := internal.GetAllCopyingInfo(from, testtools.MakeDefaultInMemoryStorageFolder())
+		assert.NoError(t, err)
+		internal.RenameCopyingInfos(infos, renameFunc)
+		err = internal.ValidateCopyingInfoTargetNames(infos)
+		assert.IsType(t, internal.InvalidCopyTargetNameError{}, err, infos[0].TargetName)
+	}
+}
+
+func makeNestedCopyingInfos(t *testing.T) []internal.CopyingInfo {
+	from := testtools.MakeDefaultInMemoryStorageFolder()
+	for _, name := range []string{"wal_005/a/000000010000000000000001", "wal_005/b/000000010000000000000001",
+		"wal_005/b/000000010000000000000002"} {
+		assert.NoError(t, from.PutObject(name, strings.NewReader(name)))
+	}
+	infos, err := internal.GetAllCopyingInfo(from, testtools.MakeDefaultInMemoryStorageFolder())
+	assert.NoError(t, err)
+	return infos
+}
+
+func getCopyingInfoTargetNames(infos []internal.CopyingInfo) []string {
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.TargetName)
+	}
+	return names
+}
+
+func TestCopyingInfos_PreserveSubfoldersByDefault(t *testing.T) {
+	infos := makeNestedCopyingInfos(t)
+	assert.ElementsMatch(t, []string{
+		"in_memory/wal_005/a/000000010000000000000001",
+		"in_memory/wal_005/b/000000010000000000000001",
+		"in_memory/wal_005/b/000000010000000000000002",
+	}, getCopyingInfoTargetNames(infos))
+}
+
+func TestFlattenCopyingInfos(t *testing.T) {
+	infos := makeNestedCopyingInfos(t)
+	internal.FlattenCopyingInfos(infos)
+	assert.ElementsMatch(t, []string{
+		"in_memory/000000010000000000000001",
+		"in_memory/000000010000000000000001",
+		"in_memory/000000010000000000000002",
+	}, getCopyingInfoTargetNames(infos))
+
+	_, err := internal.ResolveCopyingInfoCollisions(infos, internal.CopyCollisionError)
+	assert.Error(t, err)
+	resolved, err := internal.ResolveCopyingInfoCollisions(infos, internal.CopyCollisionSkip)
+	assert.NoError(t, err)
+	assert.Len(t, resolved, 2)
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// FilterCopyingInfosToRepair keeps only infos whose target object is missing in the destination
+// or differs from the source by size or content, so that repair copy leaves intact objects untouched.
+// Destination objects are read completely, source objects are read only when sizes match.
+func FilterCopyingInfosToRepair(infos []CopyingInfo) ([]CopyingInfo, error) {
+	toRepair := make([]CopyingInfo, 0)
+	for _, info := range infos {
+		isIntact, err := isCopiedObjectIntact(info)
+		if err != nil {
+			return nil, err
+		}
+		if isIntact {
+			tracelog.DebugLogger.Printf("Skipping '%s': destination object is intact", info.TargetName)
+			continue
+		}
+		toRepair = append(toRepair, info)
+	}
+	tracelog.InfoLogger.Printf("%d of %d objects should be repaired", len(toRepair), len(infos))
+	return toRepair, nil
+}
+
+func isCopiedObjectIntact(info CopyingInfo) (bool, error) {
+	targetSize, targetDigest, err := getObjectDigest(info.To, info.TargetName)
+	if _, isNotFound := errors.Cause(err).(storage.ObjectNotFoundError); isNotFound {
+		tracelog.InfoLogger.Printf("'%s' is missing in destination", info.TargetName)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if targetSize != info.Object.GetSize() {
+		tracelog.InfoLogger.Printf("'%s' size is %d, expected %d", info.TargetName, targetSize, info.Object.GetSize())
+		return false, nil
+	}
+	_, sourceDigest, err := getObjectDigest(info.From, info.Object.GetName())
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(targetDigest, sourceDigest) {
+		tracelog.InfoLogger.Printf("'%s' content differs from source '%s'", info.TargetName, info.Object.GetName())
+		return false, nil
+	}
+	return true, nil
+}
+
+func getObjectDigest(folder storage.Folder, objectName string) (int64, []byte, error) {
+	readCloser, err := folder.ReadObject(objectName)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer readCloser.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, readCloser)
+	if err != nil {
+		return 0, nil, errors.Wrapf(err, "failed to read '%s'", objectName)
+	}
+	return size, hash.Sum(nil), nil
+}
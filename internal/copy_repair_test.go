@@ -0,0 +1,47 @@
+package internal_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestFilterCopyingInfosToRepair(t *testing.T) {
+	from := testtools.MakeDefaultInMemoryStorageFolder()
+	to := testtools.MakeDefaultInMemoryStorageFolder()
+	for name, content := range map[string]string{"intact": "aaaa", "corrupted": "bbbb", "truncated": "cccc", "missing": "dddd"} {
+		assert.NoError(t, from.PutObject(name, bytes.NewReader([]byte(content))))
+	}
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+	targetNames := make(map[string]string)
+	for _, info := range infos {
+		targetNames[info.Object.GetName()] = info.TargetName
+	}
+	assert.NoError(t, to.PutObject(targetNames["intact"], bytes.NewReader([]byte("aaaa"))))
+	assert.NoError(t, to.PutObject(targetNames["corrupted"], bytes.NewReader([]byte("bxbb"))))
+	assert.NoError(t, to.PutObject(targetNames["truncated"], bytes.NewReader([]byte("cc"))))
+
+	toRepair, err := internal.FilterCopyingInfosToRepair(infos)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"corrupted", "truncated", "missing"}, getCopyingInfosNames(toRepair))
+
+	isSuccess, err := internal.StartCopy(toRepair)
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	for name, content := range map[string]string{"intact": "aaaa", "corrupted": "bbbb", "truncated": "cccc", "missing": "dddd"} {
+		readCloser, err := to.ReadObject(targetNames[name])
+		assert.NoError(t, err)
+		actual, err := ioutil.ReadAll(readCloser)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(actual))
+	}
+
+	toRepair, err = internal.FilterCopyingInfosToRepair(infos)
+	assert.NoError(t, err)
+	assert.Empty(t, toRepair)
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// TruncatedReadRetries bounds rereads of objects which ended before their expected size
+var TruncatedReadRetries = 3
+
+// MinTruncatedReadRetryWait and MaxTruncatedReadRetryWait bound waits between rereads of truncated objects
+var MinTruncatedReadRetryWait = time.Second
+var MaxTruncatedReadRetryWait = 30 * time.Second
+
+// unknownObjectSize is the expected size of objects read without checking their size
+const unknownObjectSize = int64(-1)
+
+type TruncatedCopySourceError struct {
+	error
+}
+
+func newTruncatedCopySourceError(objectName string, read, expected int64) TruncatedCopySourceError {
+	return TruncatedCopySourceError{errors.Errorf("'%s' ended after %d of %d bytes", objectName, read, expected)}
+}
+
+func (err TruncatedCopySourceError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// sizeCheckingReader fails with TruncatedCopySourceError instead of io.EOF
+// if source ends before the size it had in the listing. Some storages silently
+// return short bodies, which would otherwise be copied or restored as complete objects.
+type sizeCheckingReader struct {
+	io.ReadCloser
+	objectName   string
+	expectedSize int64
+	readSize     int64
+}
+
+func newSizeCheckingReader(readCloser io.ReadCloser, objectName string, expectedSize int64) *sizeCheckingReader {
+	return &sizeCheckingReader{ReadCloser: readCloser, objectName: objectName, expectedSize: expectedSize}
+}
+
+func (reader *sizeCheckingReader) Read(p []byte) (int, error) {
+	n, err := reader.ReadCloser.Read(p)
+	reader.readSize += int64(n)
+	if err == io.EOF && reader.readSize < reader.expectedSize {
+		return n, newTruncatedCopySourceError(reader.objectName, reader.readSize, reader.expectedSize)
+	}
+	return n, err
+}
+
+// readObjectOfSize reads object of folder, which fails with TruncatedCopySourceError
+// if it ends before expectedSize bytes. Size is not checked if it is unknownObjectSize.
+func readObjectOfSize(folder storage.Folder, objectName string, expectedSize int64) (io.ReadCloser, error) {
+	readCloser, err := folder.ReadObject(objectName)
+	if err != nil || expectedSize == unknownObjectSize {
+		return readCloser, err
+	}
+	return newSizeCheckingReader(readCloser, objectName, expectedSize), nil
+}
+
+// retryTruncatedRead repeats read up to TruncatedReadRetries times while it fails with TruncatedCopySourceError.
+// read should read its object from the start on every call.
+func retryTruncatedRead(read func() error) error {
+	retrier := newExponentialRetrier(MinTruncatedReadRetryWait, MaxTruncatedReadRetryWait)
+	for i := 0; ; i++ {
+		err := read()
+		if _, isTruncated := errors.Cause(err).(TruncatedCopySourceError); !isTruncated || i >= TruncatedReadRetries {
+			return err
+		}
+		tracelog.WarningLogger.Printf("%v, retrying (%d/%d)\n", err, i+1, TruncatedReadRetries)
+		retrier.retry()
+	}
+}
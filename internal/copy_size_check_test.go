@@ -0,0 +1,180 @@
+package internal_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/internal/compression/lz4"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+// truncatingFolder returns only first half of objects without any error, as flaky storages do.
+// Only first truncatedReads reads are truncated if it is positive.
+type truncatingFolder struct {
+	storage.Folder
+	truncatedReads int
+	reads          int
+}
+
+func (folder *truncatingFolder) ReadObject(objectRelativePath string) (io.ReadCloser, error) {
+	readCloser, err := folder.Folder.ReadObject(objectRelativePath)
+	if err != nil {
+		return nil, err
+	}
+	folder.reads++
+	if folder.truncatedReads > 0 && folder.reads > folder.truncatedReads {
+		return readCloser, nil
+	}
+	content, err := ioutil.ReadAll(readCloser)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(content[:len(content)/2])), nil
+}
+
+func withShortTruncatedReadRetryWaits(action func()) {
+	minWait, maxWait := internal.MinTruncatedReadRetryWait, internal.MaxTruncatedReadRetryWait
+	internal.MinTruncatedReadRetryWait, internal.MaxTruncatedReadRetryWait = time.Millisecond, time.Millisecond
+	defer func() {
+		internal.MinTruncatedReadRetryWait, internal.MaxTruncatedReadRetryWait = minWait, maxWait
+	}()
+	action()
+}
+
+func TestStartCopy_TruncatedSource(t *testing.T) {
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, inner.PutObject("wal_005/000000010000000000000001.lz4", bytes.NewReader(make([]byte, 100))))
+	from := &truncatingFolder{Folder: inner}
+	to := testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	withShortTruncatedReadRetryWaits(func() {
+		isSuccess, err := internal.StartCopy(infos)
+		assert.False(t, isSuccess)
+		assert.IsType(t, internal.TruncatedCopySourceError{}, errors.Cause(err))
+	})
+	assert.Equal(t, internal.TruncatedReadRetries+1, from.reads)
+	exists, err := to.Exists(infos[0].TargetName)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestStartCopy_RetriesTruncatedSource(t *testing.T) {
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	content := testtools.NewStrideByteReader(10)
+	data := make([]byte, 100)
+	_, err := io.ReadFull(content, data)
+	assert.NoError(t, err)
+	assert.NoError(t, inner.PutObject("wal_005/000000010000000000000001.lz4", bytes.NewReader(data)))
+	from := &truncatingFolder{Folder: inner, truncatedReads: 1}
+	to := testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	withShortTruncatedReadRetryWaits(func() {
+		isSuccess, err := internal.StartCopy(infos)
+		assert.True(t, isSuccess)
+		assert.NoError(t, err)
+	})
+	reader, err := to.ReadObject(infos[0].TargetName)
+	assert.NoError(t, err)
+	copied, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, data, copied)
+}
+
+func TestStorageReaderMaker_TruncatedObject(t *testing.T) {
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, inner.PutObject("part_1.tar", bytes.NewReader(make([]byte, 100))))
+	readerMaker := &internal.StorageReaderMaker{
+		Folder:       &truncatingFolder{Folder: inner},
+		RelativePath: "part_1.tar",
+		ExpectedSize: 100,
+	}
+
+	reader, err := readerMaker.Reader()
+	assert.NoError(t, err)
+	_, err = ioutil.ReadAll(reader)
+	assert.IsType(t, internal.TruncatedCopySourceError{}, err)
+}
+
+func TestExtractAll_RetriesTruncatedTar(t *testing.T) {
+	tar := &bytes.Buffer{}
+	testtools.CreateTar(tar, &io.LimitedReader{R: testtools.NewStrideByteReader(10), N: 1024})
+	tarSize := int64(tar.Len())
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, inner.PutObject("part_1.tar", tar))
+	readerMaker := &internal.StorageReaderMaker{
+		Folder:       &truncatingFolder{Folder: inner, truncatedReads: 1},
+		RelativePath: "part_1.tar",
+		ExpectedSize: tarSize,
+	}
+	minWait := internal.MinExtractRetryWait
+	internal.MinExtractRetryWait = time.Millisecond
+	defer func() { internal.MinExtractRetryWait = minWait }()
+
+	interpreter := &testtools.BufferTarInterpreter{}
+	err := internal.ExtractAll(interpreter, []internal.ReaderMaker{readerMaker})
+	assert.NoError(t, err)
+	assert.Len(t, interpreter.Out, 1024)
+}
+
+func TestDownloadWALFileTo_RetriesTruncatedSegment(t *testing.T) {
+	walName := "000000010000000000000001"
+	compressed := &bytes.Buffer{}
+	writer := lz4.Compressor{}.NewWriter(compressed)
+	_, err := writer.Write(make([]byte, internal.WalSegmentSize))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	truncated := &bytes.Buffer{}
+	writer = lz4.Compressor{}.NewWriter(truncated)
+	_, err = writer.Write(make([]byte, internal.WalSegmentSize/2))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	folder := testtools.MakeDefaultInMemoryStorageFolder().GetSubFolder(utility.WalPath)
+	assert.NoError(t, folder.PutObject(walName+"."+lz4.FileExtension, compressed))
+	shortFolder := &shortBodyOnceFolder{Folder: folder, shortBody: truncated.Bytes()}
+	dir, err := ioutil.TempDir("", "wal_fetch")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	withShortTruncatedReadRetryWaits(func() {
+		assert.NoError(t, internal.DownloadWALFileTo(shortFolder, walName, filepath.Join(dir, walName)))
+	})
+	stat, err := os.Stat(filepath.Join(dir, walName))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(internal.WalSegmentSize), stat.Size())
+}
+
+// shortBodyOnceFolder returns shortBody instead of the first object read, which is a well formed
+// compressed stream, so that only the size check notices it
+type shortBodyOnceFolder struct {
+	storage.Folder
+	shortBody []byte
+	reads     int
+}
+
+func (folder *shortBodyOnceFolder) ReadObject(objectRelativePath string) (io.ReadCloser, error) {
+	readCloser, err := folder.Folder.ReadObject(objectRelativePath)
+	if err != nil {
+		return nil, err
+	}
+	folder.reads++
+	if folder.reads > 1 {
+		return readCloser, nil
+	}
+	utility.LoggedClose(readCloser, "")
+	return ioutil.NopCloser(bytes.NewReader(folder.shortBody)), nil
+}
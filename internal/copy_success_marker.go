@@ -0,0 +1,49 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// CopySuccessMarkerName is written to the destination after successful copy,
+// so that external orchestration can tell complete copy from a partial one
+const CopySuccessMarkerName = "_SUCCESS"
+
+type CopySuccessMarkerDto struct {
+	ObjectCount int       `json:"object_count"`
+	Size        int64     `json:"size"`
+	StartTime   time.Time `json:"start_time"`
+	FinishTime  time.Time `json:"finish_time"`
+}
+
+func newCopySuccessMarkerDto(infos []CopyingInfo, startTime time.Time) CopySuccessMarkerDto {
+	return CopySuccessMarkerDto{
+		ObjectCount: len(infos),
+		Size:        getCopyingInfosSize(infos),
+		StartTime:   startTime,
+		FinishTime:  time.Now(),
+	}
+}
+
+func putCopySuccessMarker(folder storage.Folder, marker CopySuccessMarkerDto) error {
+	markerBytes, err := json.Marshal(marker)
+	if err != nil {
+		return err
+	}
+	err = folder.PutObject(CopySuccessMarkerName, bytes.NewReader(markerBytes))
+	if err != nil {
+		return errors.Wrap(err, "failed to put copy success marker")
+	}
+	tracelog.InfoLogger.Printf("Put copy success marker to '%s'\n", folder.GetPath())
+	return nil
+}
+
+func removeCopySuccessMarker(folder storage.Folder) error {
+	err := folder.DeleteObjects([]string{CopySuccessMarkerName})
+	return errors.Wrap(err, "failed to remove copy success marker")
+}
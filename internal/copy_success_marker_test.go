@@ -0,0 +1,48 @@
+package internal_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestStartCopy_PutsSuccessMarker(t *testing.T) {
+	var from = testtools.CreateMockStorageFolderWithPermanentBackups(t)
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	isSuccess, err := internal.StartCopy(infos, internal.CopyWithSuccessMarker(to))
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+
+	reader, err := to.ReadObject(internal.CopySuccessMarkerName)
+	assert.NoError(t, err)
+	markerBytes, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	var marker internal.CopySuccessMarkerDto
+	assert.NoError(t, json.Unmarshal(markerBytes, &marker))
+	assert.Equal(t, len(infos), marker.ObjectCount)
+}
+
+func TestStartCopy_RemovesSuccessMarkerOnFailure(t *testing.T) {
+	var from = testtools.MakeDefaultInMemoryStorageFolder()
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, to.PutObject(internal.CopySuccessMarkerName, strings.NewReader("{}")))
+	var objects = []storage.Object{storage.NewLocalObject("missing", time.Now(), 10)}
+	var infos = internal.BuildCopyingInfos(from, to, objects, func(object storage.Object) bool { return true })
+
+	isSuccess, err := internal.StartCopy(infos, internal.CopyWithSuccessMarker(to))
+	assert.Error(t, err)
+	assert.False(t, isSuccess)
+	exists, err := to.Exists(internal.CopySuccessMarkerName)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
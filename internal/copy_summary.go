@@ -0,0 +1,38 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+// CopySummary counts outcomes of a copy run for the final summary line
+type CopySummary struct {
+	Copied int
+	// Skipped are listed objects which were filtered out before copying
+	Skipped int
+	// Failed includes objects which were not started because copy was aborted
+	Failed   int
+	Bytes    int64
+	Duration time.Duration
+}
+
+func (summary CopySummary) String() string {
+	return fmt.Sprintf("copied=%d skipped=%d failed=%d bytes=%d duration=%s",
+		summary.Copied, summary.Skipped, summary.Failed, summary.Bytes, summary.Duration.Round(time.Millisecond))
+}
+
+// fill counts infos which are not in failures as copied, Skipped is left to the caller
+func (summary *CopySummary) fill(infos []CopyingInfo, failures []CopyFailure, duration time.Duration) {
+	failed := make(map[CopyFailure]bool, len(failures))
+	for _, failure := range failures {
+		failed[CopyFailure{SourcePath: failure.SourcePath, TargetName: failure.TargetName}] = true
+	}
+	summary.Copied, summary.Failed, summary.Bytes, summary.Duration = 0, len(failures), 0, duration
+	for _, info := range infos {
+		if failed[newCopyFailure(info, "")] {
+			continue
+		}
+		summary.Copied++
+		summary.Bytes += info.Object.GetSize()
+	}
+}
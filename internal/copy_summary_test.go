@@ -0,0 +1,46 @@
+package internal_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestCopySummary_CountsCopiedAndFailed(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	for _, name := range []string{"a", "bb", "ccc"} {
+		require.NoError(t, source.PutObject(name, strings.NewReader(name)))
+	}
+	infos, err := internal.GetAllCopyingInfo(failingReadFolder{source, "bb"}, testtools.MakeDefaultInMemoryStorageFolder())
+	require.NoError(t, err)
+
+	summary := internal.CopySummary{Skipped: 2}
+	_, err = internal.StartCopy(infos, internal.CopyWithSummary(&summary))
+
+	assert.Error(t, err)
+	assert.Equal(t, 2, summary.Skipped)
+	assert.GreaterOrEqual(t, summary.Failed, 1)
+	assert.Equal(t, len(infos), summary.Copied+summary.Failed)
+	assert.True(t, strings.HasPrefix(summary.String(), "copied="))
+	assert.Contains(t, summary.String(), " skipped=2 ")
+}
+
+func TestCopySummary_AllCopied(t *testing.T) {
+	source := testtools.MakeDefaultInMemoryStorageFolder()
+	for _, name := range []string{"a", "bb", "ccc"} {
+		require.NoError(t, source.PutObject(name, strings.NewReader(name)))
+	}
+	infos, err := internal.GetAllCopyingInfo(source, testtools.MakeDefaultInMemoryStorageFolder())
+	require.NoError(t, err)
+
+	summary := internal.CopySummary{}
+	_, err = internal.StartCopy(infos, internal.CopyWithSummary(&summary))
+
+	assert.NoError(t, err)
+	assert.Equal(t, internal.CopySummary{Copied: 3, Bytes: 6, Duration: summary.Duration}, summary)
+	assert.True(t, strings.HasPrefix(summary.String(), "copied=3 skipped=0 failed=0 bytes=6 duration="))
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// SyncPlan holds objects which SyncFolder copies and objects of destination which it deletes afterwards
+type SyncPlan struct {
+	Infos      []CopyingInfo
+	Extraneous []string
+	// Listed is the number of source objects, unchanged ones are not copied
+	Listed int
+}
+
+// SyncFolder copies objects of from which are missing in to or differ from it by size,
+// so that recurring syncs copy only new objects. Object names are kept as is.
+// With deleteExtraneous, objects of to which are absent in from are deleted afterwards.
+// Deletion is refused when from is empty, since it usually means misconfigured source.
+// Objects matching ProtectedObjectsSetting are neither copied nor deleted.
+func SyncFolder(from, to storage.Folder, deleteExtraneous bool, setters ...CopyOption) error {
+	plan, err := PlanSync(from, to, deleteExtraneous)
+	if err != nil {
+		return err
+	}
+	_, err = StartCopy(plan.Infos, setters...)
+	if err != nil {
+		return err
+	}
+	return plan.DeleteExtraneous(to)
+}
+
+// PlanSync finds objects which SyncFolder copies and, with deleteExtraneous, deletes
+func PlanSync(from, to storage.Folder, deleteExtraneous bool) (SyncPlan, error) {
+	protectedGlobs, err := getProtectedObjectGlobs()
+	if err != nil {
+		return SyncPlan{}, err
+	}
+	fromObjects, err := storage.ListFolderRecursively(from)
+	if err != nil {
+		return SyncPlan{}, errors.Wrap(err, "failed to list sync source")
+	}
+	fromObjects = FilterProtectedObjects(fromObjects, protectedGlobs)
+	toObjects, err := storage.ListFolderRecursively(to)
+	if err != nil {
+		return SyncPlan{}, errors.Wrap(err, "failed to list sync destination")
+	}
+	toObjects = FilterProtectedObjects(toObjects, protectedGlobs)
+	toSizes := make(map[string]int64, len(toObjects))
+	for _, object := range toObjects {
+		toSizes[object.GetName()] = object.GetSize()
+	}
+
+	plan := SyncPlan{Infos: make([]CopyingInfo, 0), Listed: len(fromObjects)}
+	fromNames := make(map[string]bool, len(fromObjects))
+	for _, object := range fromObjects {
+		fromNames[object.GetName()] = true
+		if size, ok := toSizes[object.GetName()]; ok && size == object.GetSize() {
+			continue
+		}
+		plan.Infos = append(plan.Infos, CopyingInfo{object, from, to, object.GetName()})
+	}
+	tracelog.InfoLogger.Printf("%d of %d objects are missing or changed in destination\n", len(plan.Infos), len(fromObjects))
+	if !deleteExtraneous {
+		return plan, nil
+	}
+
+	for _, object := range toObjects {
+		if !fromNames[object.GetName()] {
+			plan.Extraneous = append(plan.Extraneous, object.GetName())
+		}
+	}
+	if len(plan.Extraneous) > 0 && len(fromObjects) == 0 {
+		return SyncPlan{}, errors.Errorf("sync source '%s' is empty, refusing to delete %d objects from destination",
+			from.GetPath(), len(plan.Extraneous))
+	}
+	return plan, nil
+}
+
+// DeleteExtraneous deletes objects of to which are absent in sync source
+func (plan SyncPlan) DeleteExtraneous(to storage.Folder) error {
+	if len(plan.Extraneous) == 0 {
+		return nil
+	}
+	tracelog.InfoLogger.Printf("Deleting %d objects absent in source\n", len(plan.Extraneous))
+	return errors.Wrap(to.DeleteObjects(plan.Extraneous), "failed to delete extraneous objects")
+}
@@ -0,0 +1,90 @@
+package internal_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func prepareSyncFolders(t *testing.T) (storage.Folder, storage.Folder) {
+	from := testtools.MakeDefaultInMemoryStorageFolder()
+	to := testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, from.PutObject("wal_005/1", bytes.NewBufferString("same")))
+	assert.NoError(t, from.PutObject("wal_005/2", bytes.NewBufferString("changed")))
+	assert.NoError(t, from.PutObject("basebackups_005/new", bytes.NewBufferString("new")))
+	assert.NoError(t, to.PutObject("wal_005/1", bytes.NewBufferString("same")))
+	assert.NoError(t, to.PutObject("wal_005/2", bytes.NewBufferString("old")))
+	assert.NoError(t, to.PutObject("wal_005/extra", bytes.NewBufferString("extra")))
+	// objects copied again get a newer modification time
+	time.Sleep(time.Millisecond)
+	return from, to
+}
+
+func getFolderObjectNames(t *testing.T, folder storage.Folder) []string {
+	objects, err := storage.ListFolderRecursively(folder)
+	assert.NoError(t, err)
+	return getObjectNames(objects)
+}
+
+func getObjectLastModified(t *testing.T, folder storage.Folder, name string) time.Time {
+	objects, err := storage.ListFolderRecursively(folder)
+	assert.NoError(t, err)
+	for _, object := range objects {
+		if object.GetName() == name {
+			return object.GetLastModified()
+		}
+	}
+	t.Fatalf("'%s' is not found", name)
+	return time.Time{}
+}
+
+func TestSyncFolder_AddOnly(t *testing.T) {
+	from, to := prepareSyncFolders(t)
+	sameModified := getObjectLastModified(t, to, "wal_005/1")
+
+	err := internal.SyncFolder(from, to, false)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"wal_005/1", "wal_005/2", "wal_005/extra", "basebackups_005/new"},
+		getFolderObjectNames(t, to))
+	readCloser, err := to.ReadObject("wal_005/2")
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(readCloser)
+	assert.NoError(t, err)
+	assert.Equal(t, "changed", string(content))
+	assert.Equal(t, sameModified, getObjectLastModified(t, to, "wal_005/1"), "unchanged object is copied again")
+}
+
+func TestSyncFolder_MirrorWithDelete(t *testing.T) {
+	from, to := prepareSyncFolders(t)
+
+	err := internal.SyncFolder(from, to, true)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"wal_005/1", "wal_005/2", "basebackups_005/new"}, getFolderObjectNames(t, to))
+}
+
+func TestSyncFolder_RefusesToDeleteWithEmptySource(t *testing.T) {
+	_, to := prepareSyncFolders(t)
+
+	err := internal.SyncFolder(testtools.MakeDefaultInMemoryStorageFolder(), to, true)
+	assert.Error(t, err)
+	assert.Len(t, getFolderObjectNames(t, to), 3)
+}
+
+func TestPlanSync_CountsDeletedObjectsInObjectLimit(t *testing.T) {
+	from, to := prepareSyncFolders(t)
+
+	plan, err := internal.PlanSync(from, to, true)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"wal_005/2", "basebackups_005/new"}, getCopyingInfosNames(plan.Infos))
+	assert.Equal(t, []string{"wal_005/extra"}, plan.Extraneous)
+	assert.Equal(t, 3, plan.Listed)
+	assert.NoError(t, internal.CheckSyncObjectLimit(plan, 3, false))
+	assert.IsType(t, internal.CopyObjectLimitExceededError{}, internal.CheckSyncObjectLimit(plan, 2, false))
+	assert.NoError(t, internal.CheckSyncObjectLimit(plan, 2, true))
+}
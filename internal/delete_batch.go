@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// MaxDeleteBatchSize is the largest number of keys S3 accepts in one DeleteObjects request
+const MaxDeleteBatchSize = 1000
+
+// getDeleteBatchSize returns DeleteBatchSizeSetting clamped to MaxDeleteBatchSize
+func getDeleteBatchSize() (int, error) {
+	return getDeleteBatchSizeFrom(viper.GetViper())
+}
+
+func getDeleteBatchSizeFrom(config *viper.Viper) (int, error) {
+	value, ok := getSettingFrom(DeleteBatchSizeSetting, config)
+	if !ok {
+		return MaxDeleteBatchSize, nil
+	}
+	batchSize, err := strconv.Atoi(value)
+	if err != nil || batchSize <= 0 {
+		return 0, errors.Errorf("%s should be a positive integer, but is '%s'", DeleteBatchSizeSetting, value)
+	}
+	if batchSize > MaxDeleteBatchSize {
+		tracelog.WarningLogger.Printf("%s is %d, using %d\n", DeleteBatchSizeSetting, batchSize, MaxDeleteBatchSize)
+		return MaxDeleteBatchSize, nil
+	}
+	return batchSize, nil
+}
+
+// DeleteBatchingFolder passes at most batchSize names to every DeleteObjects call of the inner folder,
+// for S3-compatible storages which reject full batches
+type DeleteBatchingFolder struct {
+	storage.Folder
+	batchSize int
+}
+
+func NewDeleteBatchingFolder(inner storage.Folder, batchSize int) *DeleteBatchingFolder {
+	return &DeleteBatchingFolder{inner, batchSize}
+}
+
+func (folder *DeleteBatchingFolder) ListFolder() (objects []storage.Object, subFolders []storage.Folder, err error) {
+	objects, innerSubFolders, err := folder.Folder.ListFolder()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, subFolder := range innerSubFolders {
+		subFolders = append(subFolders, NewDeleteBatchingFolder(subFolder, folder.batchSize))
+	}
+	return objects, subFolders, nil
+}
+
+func (folder *DeleteBatchingFolder) DeleteObjects(objectRelativePaths []string) error {
+	for start := 0; start < len(objectRelativePaths); start += folder.batchSize {
+		end := start + folder.batchSize
+		if end > len(objectRelativePaths) {
+			end = len(objectRelativePaths)
+		}
+		err := folder.Folder.DeleteObjects(objectRelativePaths[start:end])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (folder *DeleteBatchingFolder) GetSubFolder(subFolderRelativePath string) storage.Folder {
+	return NewDeleteBatchingFolder(folder.Folder.GetSubFolder(subFolderRelativePath), folder.batchSize)
+}
@@ -0,0 +1,64 @@
+package internal_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestDeleteBatchingFolder_SplitsDeleteObjects(t *testing.T) {
+	var batchSizes []int
+	inner := batchRecordingFolder{testtools.MakeDefaultInMemoryStorageFolder(), &sync.Mutex{}, &batchSizes}
+	names := make([]string, 0, 7)
+	for i := 0; i < 7; i++ {
+		name := fmt.Sprintf("object_%d", i)
+		require.NoError(t, inner.PutObject(name, strings.NewReader(name)))
+		names = append(names, name)
+	}
+	folder := internal.NewDeleteBatchingFolder(inner, 3)
+
+	require.NoError(t, folder.DeleteObjects(names))
+
+	assert.Equal(t, []int{3, 3, 1}, batchSizes)
+	assert.Empty(t, getFolderObjectNames(t, inner))
+}
+
+func TestValidateSettings_InvalidDeleteBatchSize(t *testing.T) {
+	withSettings(t, map[string]string{internal.DeleteBatchSizeSetting: "0"}, func() {
+		err := internal.ValidateSettings()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), internal.DeleteBatchSizeSetting)
+	})
+}
+
+func TestValidateSettings_DeleteBatchSizeAboveMaxIsClamped(t *testing.T) {
+	withSettings(t, map[string]string{internal.DeleteBatchSizeSetting: "5000"}, func() {
+		err := internal.ValidateSettings()
+		if err != nil {
+			assert.NotContains(t, err.Error(), internal.DeleteBatchSizeSetting)
+		}
+	})
+}
+
+func TestConfigureFolderForSpecificConfig_DeleteBatchSizeFromConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "delete_batch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config := viper.New()
+	config.Set("WALG_FILE_PREFIX", dir)
+	config.Set(internal.DeleteBatchSizeSetting, "3")
+
+	folder, err := internal.ConfigureFolderForSpecificConfig(config)
+
+	require.NoError(t, err)
+	assert.IsType(t, &internal.DeleteBatchingFolder{}, folder)
+}
@@ -2,7 +2,6 @@ package internal
 
 import (
 	"fmt"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -307,7 +306,7 @@ func getRetainChoiceFunc(retentionCount, modifier int,
 }
 
 func DeleteEverything(folder storage.Folder,
-	confirmed bool,
+	confirmed, breakStaleLock bool,
 	args []string) {
 	forceModifier := false
 	modifier := extractDeleteEverythingModifierFromArgs(args)
@@ -319,11 +318,41 @@ func DeleteEverything(folder storage.Folder,
 		tracelog.ErrorLogger.Fatal(fmt.Sprintf("Found permanent objects: backups=%v, wals=%v\n", permanentBackups, permanentWals))
 	}
 
-	filter := func(object storage.Object) bool { return true }
-	err := storage.DeleteObjectsWhere(folder, confirmed, filter)
+	protectedGlobs, err := getProtectedObjectGlobs()
+	tracelog.ErrorLogger.FatalOnError(err)
+	filter := func(object storage.Object) bool { return !IsProtectedObject(object.GetName(), protectedGlobs) }
+	err = withDeleteLock(folder, confirmed, breakStaleLock, func() error {
+		return deleteObjectsWhere(folder, confirmed, filter)
+	})
 	tracelog.ErrorLogger.FatalOnError(err)
 }
 
+// withDeleteLock runs action under the storage lock unless it is a dry run, see WithConfiguredStorageLock
+func withDeleteLock(folder storage.Folder, confirmed, breakStaleLock bool, action func() error) error {
+	if !confirmed {
+		return action()
+	}
+	return WithConfiguredStorageLock(folder, "delete", breakStaleLock, action)
+}
+
+// deleteBeforeFoundTarget deletes everything before target found by findTarget, which is nil if there is nothing to delete
+func deleteBeforeFoundTarget(folder storage.Folder, confirmed, breakStaleLock bool,
+	isFullBackup func(object storage.Object) bool,
+	less func(object1, object2 storage.Object) bool,
+	findTarget func() (storage.Object, error)) error {
+	return withDeleteLock(folder, confirmed, breakStaleLock, func() error {
+		target, err := findTarget()
+		if err != nil {
+			return err
+		}
+		if target == nil {
+			tracelog.InfoLogger.Printf("No backup found for deletion")
+			return nil
+		}
+		return DeleteBeforeTarget(folder, target, confirmed, isFullBackup, less)
+	})
+}
+
 func DeleteBeforeTarget(folder storage.Folder, target storage.Object,
 	confirmed bool,
 	isFullBackup func(object storage.Object) bool,
@@ -338,8 +367,13 @@ func DeleteBeforeTarget(folder storage.Folder, target storage.Object,
 	if len(permanentBackups) > 0 {
 		tracelog.InfoLogger.Printf("Found permanent objects: backups=%v, wals=%v\n", permanentBackups, permanentWals)
 	}
-	return storage.DeleteObjectsWhere(folder, confirmed, func(object storage.Object) bool {
-		return less(object, target) && !isPermanent(object.GetName(), permanentBackups, permanentWals)
+	protectedGlobs, err := getProtectedObjectGlobs()
+	if err != nil {
+		return err
+	}
+	return deleteObjectsWhere(folder, confirmed, func(object storage.Object) bool {
+		return less(object, target) && !isPermanent(object.GetName(), permanentBackups, permanentWals) &&
+			!IsProtectedObject(object.GetName(), protectedGlobs)
 	})
 }
 
@@ -395,29 +429,24 @@ func isPermanent(objectName string, permanentBackups map[string]bool, permanentW
 	return false
 }
 
-func HandleDeleteBefore(folder storage.Folder, args []string, confirmed bool,
+func HandleDeleteBefore(folder storage.Folder, args []string, confirmed, breakStaleLock bool,
 	isFullBackup func(object storage.Object) bool,
 	less func(object1, object2 storage.Object) bool) {
 
 	modifier, beforeStr := extractDeleteModifierFromArgs(args)
 	timeLine, err := time.Parse(time.RFC3339, beforeStr)
-	var target storage.Object
-	if err == nil {
-		target, err = FindTargetBeforeTime(folder, timeLine, modifier, isFullBackup, less)
-	} else {
+	isTime := err == nil
+	err = deleteBeforeFoundTarget(folder, confirmed, breakStaleLock, isFullBackup, less, func() (storage.Object, error) {
+		if isTime {
+			return FindTargetBeforeTime(folder, timeLine, modifier, isFullBackup, less)
+		}
 		greater := func(object1, object2 storage.Object) bool { return less(object2, object1) }
-		target, err = FindTargetBeforeName(folder, beforeStr, modifier, isFullBackup, greater)
-	}
-	tracelog.ErrorLogger.FatalOnError(err)
-	if target == nil {
-		tracelog.InfoLogger.Printf("No backup found for deletion")
-		os.Exit(0)
-	}
-	err = DeleteBeforeTarget(folder, target, confirmed, isFullBackup, less)
+		return FindTargetBeforeName(folder, beforeStr, modifier, isFullBackup, greater)
+	})
 	tracelog.ErrorLogger.FatalOnError(err)
 }
 
-func HandleDeleteRetain(folder storage.Folder, args []string, confirmed bool,
+func HandleDeleteRetain(folder storage.Folder, args []string, confirmed, breakStaleLock bool,
 	isFullBackup func(object storage.Object) bool,
 	less func(object1, object2 storage.Object) bool) {
 
@@ -425,17 +454,13 @@ func HandleDeleteRetain(folder storage.Folder, args []string, confirmed bool,
 	retentionCount, err := strconv.Atoi(retantionStr)
 	tracelog.ErrorLogger.FatalOnError(err)
 	greater := func(object1, object2 storage.Object) bool { return less(object2, object1) }
-	target, err := FindTargetRetain(folder, retentionCount, modifier, isFullBackup, greater)
-	tracelog.ErrorLogger.FatalOnError(err)
-	if target == nil {
-		tracelog.InfoLogger.Printf("No backup found for deletion")
-		os.Exit(0)
-	}
-	err = DeleteBeforeTarget(folder, target, confirmed, isFullBackup, less)
+	err = deleteBeforeFoundTarget(folder, confirmed, breakStaleLock, isFullBackup, less, func() (storage.Object, error) {
+		return FindTargetRetain(folder, retentionCount, modifier, isFullBackup, greater)
+	})
 	tracelog.ErrorLogger.FatalOnError(err)
 }
 
-func HandleDeletaRetainAfter(folder storage.Folder, args []string, confirmed bool,
+func HandleDeletaRetainAfter(folder storage.Folder, args []string, confirmed, breakStaleLock bool,
 	isFullBackup func(object storage.Object) bool,
 	less func(object1, object2 storage.Object) bool) {
 
@@ -444,21 +469,14 @@ func HandleDeletaRetainAfter(folder storage.Folder, args []string, confirmed boo
 	tracelog.ErrorLogger.FatalOnError(err)
 
 	timeLine, err := time.Parse(time.RFC3339, afterStr)
+	isTime := err == nil
 	greater := func(object1, object2 storage.Object) bool { return less(object2, object1) }
-	var target storage.Object
-	if err == nil {
-		target, err = FindTargetRetainAfterTime(folder, retentionCount, timeLine, modifier, isFullBackup, greater)
-	} else {
-		target, err = FindTargetRetainAfterName(folder, retentionCount, afterStr, modifier, isFullBackup, greater)
-	}
-	tracelog.ErrorLogger.FatalOnError(err)
-
-	if target == nil {
-		tracelog.InfoLogger.Printf("No backup found for deletion")
-		os.Exit(0)
-	}
-
-	err = DeleteBeforeTarget(folder, target, confirmed, isFullBackup, less)
+	err = deleteBeforeFoundTarget(folder, confirmed, breakStaleLock, isFullBackup, less, func() (storage.Object, error) {
+		if isTime {
+			return FindTargetRetainAfterTime(folder, retentionCount, timeLine, modifier, isFullBackup, greater)
+		}
+		return FindTargetRetainAfterName(folder, retentionCount, afterStr, modifier, isFullBackup, greater)
+	})
 	tracelog.ErrorLogger.FatalOnError(err)
 }
 
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// DeletePrefix deletes all objects of folder whose relative names start with relativePrefix,
+// e.g. "basebackups_005/base_000000010000000000000002" removes both the sentinel and the backup folder.
+// Only the folder containing the prefix is listed. Names are passed to a single DeleteObjects call,
+// configured folders split it in batches of DeleteBatchSizeSetting, see DeleteBatchingFolder.
+// Protected objects are kept, see ProtectedObjectsSetting. Without confirm nothing is deleted,
+// but the number of objects which would be deleted is returned. Deletion runs under the storage lock.
+//
+// There are no retention guards here: the number of backups to keep exists only as an argument of
+// delete retain, and the storage is not told whether it is append-only. On versioned buckets
+// this removes current versions only, as other delete commands do.
+func DeletePrefix(folder storage.Folder, relativePrefix string, confirm, breakStaleLock bool) (deleted int, err error) {
+	relativePrefix = strings.TrimPrefix(relativePrefix, "/")
+	if strings.Trim(relativePrefix, "/") == "" {
+		return 0, errors.New("empty prefix would delete the whole storage")
+	}
+	protectedGlobs, err := getProtectedObjectGlobs()
+	if err != nil {
+		return 0, err
+	}
+	parent := ""
+	if index := strings.LastIndex(relativePrefix, "/"); index >= 0 {
+		parent = relativePrefix[:index+1]
+	}
+	err = withDeleteLock(folder, confirm, breakStaleLock, func() error {
+		objects, err := storage.ListFolderRecursively(folder.GetSubFolder(parent))
+		if err != nil {
+			return errors.Wrapf(err, "failed to list '%s'", parent)
+		}
+		names := make([]string, 0)
+		for _, object := range objects {
+			name := path.Join(parent, object.GetName())
+			if !strings.HasPrefix(name, relativePrefix) || IsProtectedObject(name, protectedGlobs) {
+				continue
+			}
+			tracelog.InfoLogger.Println("\twill be deleted: " + name)
+			names = append(names, name)
+		}
+		deleted = len(names)
+		if !confirm {
+			tracelog.InfoLogger.Println("Dry run, nothing were deleted")
+			return nil
+		}
+		if len(names) == 0 {
+			return nil
+		}
+		return folder.DeleteObjects(names)
+	})
+	return deleted, err
+}
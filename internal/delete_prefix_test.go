@@ -0,0 +1,93 @@
+package internal_test
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func fillFolderForPrefixDelete(t *testing.T, folder storage.Folder) {
+	names := []string{"basebackups_005/base_000000010000000000000002_backup_stop_sentinel.json",
+		"basebackups_005/base_000000010000000000000004_backup_stop_sentinel.json",
+		"basebackups_005/base_000000010000000000000004/metadata.json",
+		"wal_005/000000010000000000000002.lz4"}
+	for i := 0; i < 5; i++ {
+		names = append(names, fmt.Sprintf("basebackups_005/base_000000010000000000000002/tar_partitions/part_%d.tar.lz4", i))
+	}
+	for _, name := range names {
+		require.NoError(t, folder.PutObject(name, bytes.NewBufferString(name)))
+	}
+}
+
+func TestDeletePrefix_DeletesAllObjectsUnderPrefixInBatches(t *testing.T) {
+	var batchSizes []int
+	folder := internal.NewDeleteBatchingFolder(
+		batchRecordingFolder{testtools.MakeDefaultInMemoryStorageFolder(), &sync.Mutex{}, &batchSizes}, 3)
+	fillFolderForPrefixDelete(t, folder)
+
+	deleted, err := internal.DeletePrefix(folder, "basebackups_005/base_000000010000000000000002", true, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 6, deleted)
+	assert.Equal(t, []int{3, 3}, batchSizes)
+	assert.ElementsMatch(t, []string{"basebackups_005/base_000000010000000000000004_backup_stop_sentinel.json",
+		"basebackups_005/base_000000010000000000000004/metadata.json",
+		"wal_005/000000010000000000000002.lz4"}, getFolderObjectNames(t, folder))
+}
+
+func TestDeletePrefix_DryRunDeletesNothing(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	fillFolderForPrefixDelete(t, folder)
+	before := getFolderObjectNames(t, folder)
+
+	deleted, err := internal.DeletePrefix(folder, "basebackups_005/", false, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 8, deleted)
+	assert.ElementsMatch(t, before, getFolderObjectNames(t, folder))
+}
+
+func TestDeletePrefix_KeepsProtectedObjects(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	fillFolderForPrefixDelete(t, folder)
+	require.NoError(t, folder.PutObject("wal_005/"+internal.StorageLockObjectName, bytes.NewBufferString("lock")))
+
+	deleted, err := internal.DeletePrefix(folder, "wal_005", true, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	assert.Contains(t, getFolderObjectNames(t, folder), "wal_005/"+internal.StorageLockObjectName)
+}
+
+func TestDeletePrefix_RejectsEmptyPrefix(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+
+	_, err := internal.DeletePrefix(folder, "/", true, false)
+
+	assert.Error(t, err)
+}
+
+func TestDeletePrefix_RefusedWhileStorageIsLocked(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	fillFolderForPrefixDelete(t, folder)
+	before := getFolderObjectNames(t, folder)
+
+	withSettings(t, map[string]string{internal.StorageLockTTLSetting: "1h"}, func() {
+		lock, err := internal.AcquireStorageLock(folder, "backup-push", time.Hour, false)
+		require.NoError(t, err)
+		defer lock.Release()
+
+		_, err = internal.DeletePrefix(folder, "wal_005", true, false)
+
+		assert.IsType(t, internal.StorageLockedError{}, err)
+	})
+	assert.ElementsMatch(t, before, getFolderObjectNames(t, folder))
+}
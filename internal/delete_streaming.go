@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultDeleteConcurrency is the number of DeleteObjects requests sent at the same time by delete commands
+const DefaultDeleteConcurrency = 4
+
+// getDeleteConcurrency returns DeleteConcurrencySetting or DefaultDeleteConcurrency
+func getDeleteConcurrency() (int, error) {
+	value, ok := GetSetting(DeleteConcurrencySetting)
+	if !ok {
+		return DefaultDeleteConcurrency, nil
+	}
+	concurrency, err := strconv.Atoi(value)
+	if err != nil || concurrency <= 0 {
+		return 0, errors.Errorf("%s should be a positive integer, but is '%s'", DeleteConcurrencySetting, value)
+	}
+	return concurrency, nil
+}
+
+// deleteObjectsWhere is DeleteObjectsWhereStreaming with DeleteBatchSizeSetting and DeleteConcurrencySetting
+func deleteObjectsWhere(folder storage.Folder, confirm bool, filter func(object storage.Object) bool) error {
+	batchSize, err := getDeleteBatchSize()
+	if err != nil {
+		return err
+	}
+	concurrency, err := getDeleteConcurrency()
+	if err != nil {
+		return err
+	}
+	return DeleteObjectsWhereStreaming(folder, confirm, filter, batchSize, concurrency)
+}
+
+// DeleteObjectsWhereStreaming deletes the same objects as storage.DeleteObjectsWhere,
+// but sends them to DeleteObjects in batches of batchSize while the folder is still being listed,
+// so at most concurrency batches are kept in memory instead of the whole listing.
+// After the first failed batch no new batches are sent and listing stops.
+func DeleteObjectsWhereStreaming(folder storage.Folder, confirm bool,
+	filter func(object storage.Object) bool, batchSize, concurrency int) error {
+	if batchSize <= 0 || concurrency <= 0 {
+		return errors.Errorf("batch size and concurrency must be positive, got %d and %d", batchSize, concurrency)
+	}
+	// failed is canceled by the failed batch before it frees its slot, so no batch starts after it
+	failed, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errorGroup := new(errgroup.Group)
+	slots := make(chan struct{}, concurrency)
+	deleteBatch := func(batch []string) {
+		if !confirm {
+			return
+		}
+		slots <- struct{}{}
+		if failed.Err() != nil {
+			<-slots
+			return
+		}
+		errorGroup.Go(func() error {
+			err := folder.DeleteObjects(batch)
+			if err != nil {
+				cancel()
+			}
+			<-slots
+			return err
+		})
+	}
+
+	batch := make([]string, 0, batchSize)
+	queue := []storage.Folder{folder}
+	for len(queue) > 0 && failed.Err() == nil {
+		subFolder := queue[0]
+		queue = queue[1:]
+		objects, subFolders, err := subFolder.ListFolder()
+		if err != nil {
+			_ = errorGroup.Wait()
+			return err
+		}
+		folderPrefix := strings.TrimPrefix(subFolder.GetPath(), folder.GetPath())
+		for _, object := range objects {
+			relativePath := path.Join(folderPrefix, object.GetName())
+			if !filter(storage.NewLocalObject(relativePath, object.GetLastModified(), object.GetSize())) {
+				tracelog.DebugLogger.Println("\tskipped: " + relativePath)
+				continue
+			}
+			tracelog.InfoLogger.Println("\twill be deleted: " + relativePath)
+			batch = append(batch, relativePath)
+			if len(batch) == batchSize {
+				deleteBatch(batch)
+				batch = make([]string, 0, batchSize)
+			}
+		}
+		queue = append(queue, subFolders...)
+	}
+	if len(batch) > 0 {
+		deleteBatch(batch)
+	}
+	if !confirm {
+		tracelog.InfoLogger.Println("Dry run, nothing were deleted")
+	}
+	return errorGroup.Wait()
+}
@@ -0,0 +1,118 @@
+package internal_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+type batchRecordingFolder struct {
+	storage.Folder
+	mutex      *sync.Mutex
+	batchSizes *[]int
+}
+
+func (folder batchRecordingFolder) DeleteObjects(objectRelativePaths []string) error {
+	folder.mutex.Lock()
+	*folder.batchSizes = append(*folder.batchSizes, len(objectRelativePaths))
+	folder.mutex.Unlock()
+	return folder.Folder.DeleteObjects(objectRelativePaths)
+}
+
+func fillFolderForStreamingDelete(t *testing.T, folder storage.Folder) {
+	for _, dir := range []string{"basebackups_005", "wal_005", "wal_005/sub"} {
+		for i := 0; i < 7; i++ {
+			name := fmt.Sprintf("%s/object_%d", dir, i)
+			require.NoError(t, folder.PutObject(name, bytes.NewBufferString(name)))
+		}
+	}
+}
+
+func TestDeleteObjectsWhereStreaming_DeletesSameObjectsInBatches(t *testing.T) {
+	filter := func(object storage.Object) bool {
+		return strings.HasPrefix(object.GetName(), "wal_005/") || strings.HasSuffix(object.GetName(), "_3")
+	}
+
+	collectedFolder := testtools.MakeDefaultInMemoryStorageFolder()
+	fillFolderForStreamingDelete(t, collectedFolder)
+	require.NoError(t, storage.DeleteObjectsWhere(collectedFolder, true, filter))
+
+	var batchSizes []int
+	streamedFolder := batchRecordingFolder{testtools.MakeDefaultInMemoryStorageFolder(), &sync.Mutex{}, &batchSizes}
+	fillFolderForStreamingDelete(t, streamedFolder)
+	require.NoError(t, internal.DeleteObjectsWhereStreaming(streamedFolder, true, filter, 4, 2))
+
+	assert.ElementsMatch(t, getFolderObjectNames(t, collectedFolder), getFolderObjectNames(t, streamedFolder))
+	total := 0
+	for _, size := range batchSizes {
+		assert.LessOrEqual(t, size, 4)
+		total += size
+	}
+	assert.Equal(t, 15, total)
+}
+
+func TestDeleteObjectsWhereStreaming_DryRunDeletesNothing(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	fillFolderForStreamingDelete(t, folder)
+
+	err := internal.DeleteObjectsWhereStreaming(folder, false, func(storage.Object) bool { return true }, 4, 2)
+
+	require.NoError(t, err)
+	assert.Len(t, getFolderObjectNames(t, folder), 21)
+}
+
+func TestDeleteObjectsWhereStreaming_RejectsNonPositiveBatchSize(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	err := internal.DeleteObjectsWhereStreaming(folder, true, func(storage.Object) bool { return true }, 0, 2)
+	assert.Error(t, err)
+}
+
+type failingDeleteFolder struct {
+	batchRecordingFolder
+}
+
+func (folder failingDeleteFolder) DeleteObjects(objectRelativePaths []string) error {
+	_ = folder.batchRecordingFolder.DeleteObjects(objectRelativePaths)
+	return errors.New("delete failed")
+}
+
+func TestDeleteObjectsWhereStreaming_StopsAfterFailedBatch(t *testing.T) {
+	var batchSizes []int
+	folder := failingDeleteFolder{batchRecordingFolder{testtools.MakeDefaultInMemoryStorageFolder(), &sync.Mutex{}, &batchSizes}}
+	fillFolderForStreamingDelete(t, folder)
+
+	err := internal.DeleteObjectsWhereStreaming(folder, true, func(storage.Object) bool { return true }, 2, 1)
+
+	assert.Error(t, err)
+	assert.Equal(t, []int{2}, batchSizes)
+}
+
+func TestDeleteBeforeTarget_DeletesInConfiguredBatches(t *testing.T) {
+	var batchSizes []int
+	folder := batchRecordingFolder{testtools.MakeDefaultInMemoryStorageFolder(), &sync.Mutex{}, &batchSizes}
+	for i := 1; i <= 7; i++ {
+		name := fmt.Sprintf("wal_005/00000001000000000000000%d.lz4", i)
+		require.NoError(t, folder.PutObject(name, bytes.NewBufferString(name)))
+	}
+	target := storage.NewLocalObject("wal_005/000000010000000000000006.lz4", time.Now(), 0)
+	less := func(object1, object2 storage.Object) bool { return object1.GetName() < object2.GetName() }
+	isFullBackup := func(object storage.Object) bool { return true }
+
+	withSettings(t, map[string]string{internal.DeleteBatchSizeSetting: "3"}, func() {
+		require.NoError(t, internal.DeleteBeforeTarget(folder, target, true, isFullBackup, less))
+	})
+
+	assert.ElementsMatch(t, []int{3, 2}, batchSizes)
+	assert.ElementsMatch(t, []string{"wal_005/000000010000000000000006.lz4", "wal_005/000000010000000000000007.lz4"},
+		getFolderObjectNames(t, folder))
+}
@@ -1,20 +1,94 @@
 package internal
 
-import "time"
+import (
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/tracelog"
+)
+
+// RetryBackoff describes waits between retries: the first wait is BaseDelay, every next one is
+// Multiplier times longer up to MaxDelay, and each wait is shortened by a random part of up to Jitter of it
+type RetryBackoff struct {
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// getRetryBackoff returns backoff of RetryBaseDelaySetting, RetryMaxDelaySetting, RetryMultiplierSetting
+// and RetryJitterSetting, unset delays are taken from arguments, so every retry point keeps its own scale by default
+func getRetryBackoff(defaultBaseDelay, defaultMaxDelay time.Duration) (RetryBackoff, error) {
+	backoff := RetryBackoff{BaseDelay: defaultBaseDelay, MaxDelay: defaultMaxDelay, Multiplier: 2}
+	var err error
+	baseValue, baseIsSet := GetSetting(RetryBaseDelaySetting)
+	if baseIsSet {
+		backoff.BaseDelay, err = time.ParseDuration(baseValue)
+		if err != nil || backoff.BaseDelay <= 0 {
+			return RetryBackoff{}, errors.Errorf("%s should be a positive duration, but is '%s'", RetryBaseDelaySetting, baseValue)
+		}
+	}
+	maxValue, maxIsSet := GetSetting(RetryMaxDelaySetting)
+	if maxIsSet {
+		backoff.MaxDelay, err = time.ParseDuration(maxValue)
+		if err != nil || backoff.MaxDelay <= 0 {
+			return RetryBackoff{}, errors.Errorf("%s should be a positive duration, but is '%s'", RetryMaxDelaySetting, maxValue)
+		}
+	}
+	if backoff.MaxDelay < backoff.BaseDelay {
+		switch {
+		case baseIsSet && maxIsSet:
+			return RetryBackoff{}, errors.Errorf("%s %s is less than %s %s",
+				RetryMaxDelaySetting, backoff.MaxDelay, RetryBaseDelaySetting, backoff.BaseDelay)
+		case maxIsSet:
+			backoff.BaseDelay = backoff.MaxDelay
+		default:
+			backoff.MaxDelay = backoff.BaseDelay
+		}
+	}
+	if value, ok := GetSetting(RetryMultiplierSetting); ok {
+		backoff.Multiplier, err = strconv.ParseFloat(value, 64)
+		if err != nil || backoff.Multiplier < 1 {
+			return RetryBackoff{}, errors.Errorf("%s should be a number not less than 1, but is '%s'", RetryMultiplierSetting, value)
+		}
+	}
+	if value, ok := GetSetting(RetryJitterSetting); ok {
+		backoff.Jitter, err = strconv.ParseFloat(value, 64)
+		if err != nil || backoff.Jitter < 0 || backoff.Jitter > 1 {
+			return RetryBackoff{}, errors.Errorf("%s should be a number from 0 to 1, but is '%s'", RetryJitterSetting, value)
+		}
+	}
+	return backoff, nil
+}
 
 type ExponentialRetrier struct {
-	sleepDuration      time.Duration
-	sleepDurationBound time.Duration
+	backoff       RetryBackoff
+	sleepDuration time.Duration
 }
 
 func newExponentialRetrier(startSleepDuration, sleepDurationBound time.Duration) *ExponentialRetrier {
-	return &ExponentialRetrier{startSleepDuration, sleepDurationBound}
+	backoff, err := getRetryBackoff(startSleepDuration, sleepDurationBound)
+	if err != nil {
+		tracelog.WarningLogger.Printf("%v, using default retry backoff\n", err)
+		backoff = RetryBackoff{BaseDelay: startSleepDuration, MaxDelay: sleepDurationBound, Multiplier: 2}
+	}
+	return &ExponentialRetrier{backoff, backoff.BaseDelay}
 }
 
 func (retrier *ExponentialRetrier) retry() {
-	time.Sleep(retrier.sleepDuration)
-	retrier.sleepDuration *= 2
-	if retrier.sleepDuration > retrier.sleepDurationBound {
-		retrier.sleepDuration = retrier.sleepDurationBound
+	time.Sleep(retrier.nextDelay())
+}
+
+func (retrier *ExponentialRetrier) nextDelay() time.Duration {
+	delay := retrier.sleepDuration
+	if retrier.backoff.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * retrier.backoff.Jitter * float64(delay))
+	}
+	retrier.sleepDuration = time.Duration(float64(retrier.sleepDuration) * retrier.backoff.Multiplier)
+	if retrier.sleepDuration > retrier.backoff.MaxDelay {
+		retrier.sleepDuration = retrier.backoff.MaxDelay
 	}
+	return delay
 }
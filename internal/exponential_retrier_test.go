@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponentialRetrier_DelaysGrowToMaxDelay(t *testing.T) {
+	retrier := newExponentialRetrier(time.Second, 5*time.Second)
+
+	delays := make([]time.Duration, 0)
+	for i := 0; i < 5; i++ {
+		delays = append(delays, retrier.nextDelay())
+	}
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}, delays)
+}
+
+func TestExponentialRetrier_ConfiguredBackoffStaysWithinBounds(t *testing.T) {
+	settings := map[string]string{RetryBaseDelaySetting: "100ms", RetryMaxDelaySetting: "1s",
+		RetryMultiplierSetting: "3", RetryJitterSetting: "0.5"}
+	for name, value := range settings {
+		viper.Set(name, value)
+	}
+	defer func() {
+		for name := range settings {
+			viper.Set(name, nil)
+		}
+	}()
+	retrier := newExponentialRetrier(time.Minute, 5*time.Minute)
+	require.Equal(t, RetryBackoff{100 * time.Millisecond, time.Second, 3, 0.5}, retrier.backoff)
+
+	expected := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second, time.Second}
+	for _, full := range expected {
+		delay := retrier.nextDelay()
+		assert.True(t, delay > full/2 && delay <= full, "delay %s is out of (%s, %s]", delay, full/2, full)
+	}
+}
+
+func TestGetRetryBackoff_RejectsInvalidValues(t *testing.T) {
+	for name, value := range map[string]string{RetryBaseDelaySetting: "0s", RetryMaxDelaySetting: "soon",
+		RetryMultiplierSetting: "0.5", RetryJitterSetting: "2"} {
+		viper.Set(name, value)
+		_, err := getRetryBackoff(time.Second, time.Minute)
+		viper.Set(name, nil)
+		assert.Error(t, err, name)
+		if err != nil {
+			assert.Contains(t, err.Error(), name)
+		}
+	}
+}
+
+func TestGetRetryBackoff_SingleDelayAdjustsDefaultOne(t *testing.T) {
+	viper.Set(RetryMaxDelaySetting, "10s")
+	backoff, err := getRetryBackoff(time.Minute, 5*time.Minute)
+	viper.Set(RetryMaxDelaySetting, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, backoff.BaseDelay)
+	assert.Equal(t, 10*time.Second, backoff.MaxDelay)
+}
@@ -21,6 +21,9 @@ import (
 var MinExtractRetryWait = time.Minute
 var MaxExtractRetryWait = 5 * time.Minute
 
+// FetchProgressBar accounts files extracted by ExtractAll and bytes read from storage, nil disables it
+var FetchProgressBar *ProgressBar
+
 type NoFilesToExtractError struct {
 	error
 }
@@ -152,12 +155,26 @@ func ExtractAll(tarInterpreter TarInterpreter, files []ReaderMaker) error {
 	if err != nil {
 		return err
 	}
+	heartbeatInterval, err := getFetchHeartbeatInterval()
+	if err != nil {
+		return err
+	}
+	heartbeat := startFetchHeartbeat(heartbeatInterval, len(files), logFetchHeartbeat)
+	defer heartbeat.stop()
+	FetchProgressBar.AddTotal(int64(len(files)), 0)
+	truncatedRetries := 0
 	for currentRun := files; len(currentRun) > 0; {
-		var failed []ReaderMaker
-		failed = tryExtractFiles(currentRun, tarInterpreter, downloadingConcurrency)
+		failed, truncatedCount := tryExtractFiles(currentRun, tarInterpreter, downloadingConcurrency, heartbeat)
 		if downloadingConcurrency > 1 {
 			downloadingConcurrency /= 2
 		} else if len(failed) == len(currentRun) {
+			// files which storage returned truncated are extracted again up to TruncatedReadRetries times
+			if truncatedCount == len(failed) && truncatedRetries < TruncatedReadRetries {
+				truncatedRetries++
+				tracelog.WarningLogger.Printf("Retrying truncated files (%d/%d)\n", truncatedRetries, TruncatedReadRetries)
+				retrier.retry()
+				continue
+			}
 			return errors.Errorf("failed to extract files:\n%s\n",
 				strings.Join(readerMakersToFilePaths(failed), "\n"))
 		}
@@ -170,11 +187,13 @@ func ExtractAll(tarInterpreter TarInterpreter, files []ReaderMaker) error {
 }
 
 // TODO : unit tests
-func tryExtractFiles(files []ReaderMaker, tarInterpreter TarInterpreter, downloadingConcurrency int) (failed []ReaderMaker) {
+func tryExtractFiles(files []ReaderMaker, tarInterpreter TarInterpreter, downloadingConcurrency int,
+	heartbeat *fetchHeartbeat) (failed []ReaderMaker, truncatedCount int) {
 	downloadingContext := context.TODO()
 	downloadingSemaphore := semaphore.NewWeighted(int64(downloadingConcurrency))
 	crypter := ConfigureCrypter()
 	isFailed := sync.Map{}
+	isTruncated := sync.Map{}
 
 	for _, file := range files {
 		_ = downloadingSemaphore.Acquire(downloadingContext, 1)
@@ -183,17 +202,26 @@ func tryExtractFiles(files []ReaderMaker, tarInterpreter TarInterpreter, downloa
 		extractingReader, pipeWriter := io.Pipe()
 		decompressingWriter := &EmptyWriteIgnorer{pipeWriter}
 		go func() {
-			err := DecryptAndDecompressTar(decompressingWriter, fileClosure, crypter)
-			utility.LoggedClose(decompressingWriter, "")
-			tracelog.InfoLogger.Printf("Finished decompression of %s", fileClosure.Path())
+			err := DecryptAndDecompressTar(decompressingWriter, progressReaderMaker{fileClosure, FetchProgressBar}, crypter)
+			// failure is stored before closing the pipe, which lets the extraction finish
 			if err != nil {
 				isFailed.Store(fileClosure, true)
+				if _, ok := errors.Cause(err).(TruncatedCopySourceError); ok {
+					isTruncated.Store(fileClosure, true)
+				}
 				tracelog.ErrorLogger.Println(fileClosure.Path(), err)
 			}
+			utility.LoggedClose(decompressingWriter, "")
+			tracelog.InfoLogger.Printf("Finished decompression of %s", fileClosure.Path())
 		}()
 		go func() {
 			defer downloadingSemaphore.Release(1)
+			heartbeat.startFile(fileClosure.Path())
 			err := extractOne(tarInterpreter, extractingReader)
+			heartbeat.finishFile(fileClosure.Path(), err == nil)
+			if err == nil {
+				FetchProgressBar.AddObject()
+			}
 			err = errors.Wrapf(err, "Extraction error in %s", fileClosure.Path())
 			utility.LoggedClose(extractingReader, "")
 			tracelog.InfoLogger.Printf("Finished extraction of %s", fileClosure.Path())
@@ -207,7 +235,10 @@ func tryExtractFiles(files []ReaderMaker, tarInterpreter TarInterpreter, downloa
 	_ = downloadingSemaphore.Acquire(downloadingContext, int64(downloadingConcurrency))
 	isFailed.Range(func(failedFile, _ interface{}) bool {
 		failed = append(failed, failedFile.(ReaderMaker))
+		if _, ok := isTruncated.Load(failedFile); ok {
+			truncatedCount++
+		}
 		return true
 	})
-	return failed
+	return failed, truncatedCount
 }
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/tracelog"
+)
+
+// getFetchHeartbeatInterval returns FetchHeartbeatIntervalSetting, 0 means heartbeat is disabled
+func getFetchHeartbeatInterval() (time.Duration, error) {
+	value, ok := GetSetting(FetchHeartbeatIntervalSetting)
+	if !ok {
+		return 0, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		return 0, errors.Errorf("%s should be a non-negative duration, but is '%s'", FetchHeartbeatIntervalSetting, value)
+	}
+	return interval, nil
+}
+
+// fetchHeartbeat periodically reports extraction progress, so that a fetch which spends
+// a long time unpacking isn't taken for a hung one
+type fetchHeartbeat struct {
+	mutex      sync.Mutex
+	inProgress map[string]bool
+	finished   int
+	total      int
+	log        func(message string)
+	done       chan struct{}
+	stopped    sync.WaitGroup
+}
+
+// startFetchHeartbeat reports progress of total files every interval until stop, zero interval disables reports
+func startFetchHeartbeat(interval time.Duration, total int, log func(message string)) *fetchHeartbeat {
+	heartbeat := &fetchHeartbeat{inProgress: make(map[string]bool), total: total, log: log, done: make(chan struct{})}
+	if interval <= 0 {
+		return heartbeat
+	}
+	heartbeat.stopped.Add(1)
+	go func() {
+		defer heartbeat.stopped.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				heartbeat.log(heartbeat.message())
+			case <-heartbeat.done:
+				return
+			}
+		}
+	}()
+	return heartbeat
+}
+
+func (heartbeat *fetchHeartbeat) startFile(path string) {
+	heartbeat.mutex.Lock()
+	defer heartbeat.mutex.Unlock()
+	heartbeat.inProgress[path] = true
+}
+
+// finishFile counts only extracted files, failed ones are retried and finished again
+func (heartbeat *fetchHeartbeat) finishFile(path string, extracted bool) {
+	heartbeat.mutex.Lock()
+	defer heartbeat.mutex.Unlock()
+	delete(heartbeat.inProgress, path)
+	if extracted {
+		heartbeat.finished++
+	}
+}
+
+func (heartbeat *fetchHeartbeat) message() string {
+	heartbeat.mutex.Lock()
+	defer heartbeat.mutex.Unlock()
+	paths := make([]string, 0, len(heartbeat.inProgress))
+	for path := range heartbeat.inProgress {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return fmt.Sprintf("Fetch is in progress: %d of %d files are extracted, extracting: %s",
+		heartbeat.finished, heartbeat.total, strings.Join(paths, ", "))
+}
+
+func (heartbeat *fetchHeartbeat) stop() {
+	close(heartbeat.done)
+	heartbeat.stopped.Wait()
+}
+
+func logFetchHeartbeat(message string) {
+	tracelog.InfoLogger.Println(message)
+}
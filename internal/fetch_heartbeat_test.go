@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchHeartbeat_FiresEveryIntervalDuringSlowFetch(t *testing.T) {
+	var mutex sync.Mutex
+	messages := make([]string, 0)
+	heartbeat := startFetchHeartbeat(20*time.Millisecond, 2, func(message string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		messages = append(messages, message)
+	})
+
+	heartbeat.startFile("base_000/tar_partitions/part_1.tar.lz4")
+	time.Sleep(110 * time.Millisecond)
+	heartbeat.finishFile("base_000/tar_partitions/part_1.tar.lz4", true)
+	heartbeat.startFile("base_000/tar_partitions/part_2.tar.lz4")
+	time.Sleep(50 * time.Millisecond)
+	heartbeat.stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.GreaterOrEqual(t, len(messages), 5)
+	assert.LessOrEqual(t, len(messages), 8)
+	assert.Equal(t, "Fetch is in progress: 0 of 2 files are extracted, extracting: base_000/tar_partitions/part_1.tar.lz4",
+		messages[0])
+	assert.Equal(t, "Fetch is in progress: 1 of 2 files are extracted, extracting: base_000/tar_partitions/part_2.tar.lz4",
+		messages[len(messages)-1])
+}
+
+func TestFetchHeartbeat_ZeroIntervalIsSilent(t *testing.T) {
+	heartbeat := startFetchHeartbeat(0, 1, func(message string) {
+		t.Errorf("unexpected heartbeat: %s", message)
+	})
+
+	heartbeat.startFile("part_1.tar.lz4")
+	time.Sleep(10 * time.Millisecond)
+	heartbeat.finishFile("part_1.tar.lz4", true)
+	heartbeat.stop()
+}
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/wal-g/storages/storage"
+)
+
+// FolderContentDigest hashes names and sizes of all objects in folder, sorted by name,
+// so that folders with the same objects get the same digest regardless of storage type.
+// Object contents are not read, ETags are not available through storage.Folder.
+func FolderContentDigest(folder storage.Folder) (string, error) {
+	objects, err := storage.ListFolderRecursively(folder)
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].GetName() < objects[j].GetName()
+	})
+	hash := sha256.New()
+	for _, object := range objects {
+		_, err = fmt.Fprintf(hash, "%s\x00%d\n", object.GetName(), object.GetSize())
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
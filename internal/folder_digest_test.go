@@ -0,0 +1,39 @@
+package internal_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func makeDigestFolder(t *testing.T, objects map[string]string) storage.Folder {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	for name, content := range objects {
+		assert.NoError(t, folder.PutObject(name, bytes.NewBufferString(content)))
+	}
+	return folder
+}
+
+func TestFolderContentDigest(t *testing.T) {
+	objects := map[string]string{"wal_005/1": "aa", "wal_005/2": "bbb", "basebackups_005/base_1/metadata.json": "{}"}
+	digest, err := internal.FolderContentDigest(makeDigestFolder(t, objects))
+	assert.NoError(t, err)
+	sameDigest, err := internal.FolderContentDigest(makeDigestFolder(t, objects))
+	assert.NoError(t, err)
+	assert.Equal(t, digest, sameDigest)
+
+	objects["wal_005/2"] = "bbbb"
+	changedDigest, err := internal.FolderContentDigest(makeDigestFolder(t, objects))
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, changedDigest)
+
+	delete(objects, "wal_005/2")
+	removedDigest, err := internal.FolderContentDigest(makeDigestFolder(t, objects))
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, removedDigest)
+	assert.NotEqual(t, changedDigest, removedDigest)
+}
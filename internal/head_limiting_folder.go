@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wal-g/storages/storage"
+)
+
+// getHeadConcurrency returns HeadConcurrencySetting, 0 means head calls are not limited
+func getHeadConcurrency() (int, error) {
+	return getHeadConcurrencyFrom(viper.GetViper())
+}
+
+func getHeadConcurrencyFrom(config *viper.Viper) (int, error) {
+	value, ok := getSettingFrom(HeadConcurrencySetting, config)
+	if !ok {
+		return 0, nil
+	}
+	concurrency, err := strconv.Atoi(value)
+	if err != nil || concurrency < 0 {
+		return 0, errors.Errorf("%s should be a non-negative integer, but is '%s'", HeadConcurrencySetting, value)
+	}
+	return concurrency, nil
+}
+
+// HeadLimitingFolder runs at most cap(slots) Exists calls of the inner folder at once,
+// independently of upload and download concurrency. Sub folders share the same slots.
+type HeadLimitingFolder struct {
+	storage.Folder
+	slots chan struct{}
+}
+
+func NewHeadLimitingFolder(inner storage.Folder, concurrency int) *HeadLimitingFolder {
+	return &HeadLimitingFolder{inner, make(chan struct{}, concurrency)}
+}
+
+func (folder *HeadLimitingFolder) Exists(objectRelativePath string) (bool, error) {
+	folder.slots <- struct{}{}
+	defer func() { <-folder.slots }()
+	return folder.Folder.Exists(objectRelativePath)
+}
+
+func (folder *HeadLimitingFolder) ListFolder() (objects []storage.Object, subFolders []storage.Folder, err error) {
+	objects, innerSubFolders, err := folder.Folder.ListFolder()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, subFolder := range innerSubFolders {
+		subFolders = append(subFolders, &HeadLimitingFolder{subFolder, folder.slots})
+	}
+	return objects, subFolders, nil
+}
+
+func (folder *HeadLimitingFolder) GetSubFolder(subFolderRelativePath string) storage.Folder {
+	return &HeadLimitingFolder{folder.Folder.GetSubFolder(subFolderRelativePath), folder.slots}
+}
@@ -0,0 +1,82 @@
+package internal_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+type concurrencyRecordingFolder struct {
+	storage.Folder
+	mutex   *sync.Mutex
+	current *int
+	maximum *int
+}
+
+func (folder concurrencyRecordingFolder) Exists(objectRelativePath string) (bool, error) {
+	folder.mutex.Lock()
+	*folder.current++
+	if *folder.current > *folder.maximum {
+		*folder.maximum = *folder.current
+	}
+	folder.mutex.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	folder.mutex.Lock()
+	*folder.current--
+	folder.mutex.Unlock()
+	return folder.Folder.Exists(objectRelativePath)
+}
+
+func TestHeadLimitingFolder_BoundsConcurrentExists(t *testing.T) {
+	var current, maximum int
+	inner := concurrencyRecordingFolder{testtools.MakeDefaultInMemoryStorageFolder(), &sync.Mutex{}, &current, &maximum}
+	require.NoError(t, inner.PutObject("object_0", strings.NewReader("object")))
+	folder := internal.NewHeadLimitingFolder(inner, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			exists, err := folder.Exists(fmt.Sprintf("object_%d", i))
+			assert.NoError(t, err)
+			assert.Equal(t, i == 0, exists)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maximum, 2)
+}
+
+func TestValidateSettings_InvalidHeadConcurrency(t *testing.T) {
+	withSettings(t, map[string]string{internal.HeadConcurrencySetting: "-1"}, func() {
+		err := internal.ValidateSettings()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), internal.HeadConcurrencySetting)
+	})
+}
+
+func TestConfigureFolderForSpecificConfig_HeadConcurrencyFromConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "head_limiting")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	config := viper.New()
+	config.Set("WALG_FILE_PREFIX", dir)
+	config.Set(internal.HeadConcurrencySetting, "2")
+
+	folder, err := internal.ConfigureFolderForSpecificConfig(config)
+
+	require.NoError(t, err)
+	assert.IsType(t, &internal.HeadLimitingFolder{}, folder)
+}
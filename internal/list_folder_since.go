@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+)
+
+// listMarker is the latest modification time of listed objects
+// with names of objects modified exactly at that time, which were listed already.
+// Objects put later with the same modification time are listed by the next call.
+type listMarker struct {
+	Time  time.Time `json:"time"`
+	Names []string  `json:"names,omitempty"`
+}
+
+// parseListMarker also accepts a bare RFC3339Nano time,
+// objects modified exactly at that time are listed again then
+func parseListMarker(marker string) (listMarker, error) {
+	if marker == "" {
+		return listMarker{}, nil
+	}
+	var parsed listMarker
+	err := json.Unmarshal([]byte(marker), &parsed)
+	if err == nil {
+		return parsed, nil
+	}
+	parsed.Time, err = time.Parse(time.RFC3339Nano, marker)
+	if err != nil {
+		return listMarker{}, errors.Wrapf(err, "invalid list marker '%s'", marker)
+	}
+	return parsed, nil
+}
+
+// ListFolderSince recursively lists objects of folder modified since the marker
+// and returns them with a marker for the next call. Empty marker lists all objects.
+// Marker is a JSON string, so it can be stored and passed to the next recurring sync.
+func ListFolderSince(folder storage.Folder, marker string) ([]storage.Object, string, error) {
+	since, err := parseListMarker(marker)
+	if err != nil {
+		return nil, "", err
+	}
+	listedAtSince := make(map[string]bool, len(since.Names))
+	for _, name := range since.Names {
+		listedAtSince[name] = true
+	}
+	objects, err := storage.ListFolderRecursively(folder)
+	if err != nil {
+		return nil, "", err
+	}
+	changed := make([]storage.Object, 0)
+	latest := since.Time
+	for _, object := range objects {
+		modified := object.GetLastModified()
+		if modified.Before(since.Time) || modified.Equal(since.Time) && listedAtSince[object.GetName()] {
+			continue
+		}
+		changed = append(changed, object)
+		if modified.After(latest) {
+			latest = modified
+		}
+	}
+	if latest.IsZero() {
+		return changed, marker, nil
+	}
+	next := listMarker{Time: latest.UTC(), Names: make([]string, 0)}
+	for _, object := range objects {
+		if object.GetLastModified().Equal(latest) {
+			next.Names = append(next.Names, object.GetName())
+		}
+	}
+	sort.Strings(next.Names)
+	nextMarker, err := json.Marshal(next)
+	if err != nil {
+		return nil, "", err
+	}
+	return changed, string(nextMarker), nil
+}
@@ -0,0 +1,92 @@
+package internal_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func getObjectNames(objects []storage.Object) []string {
+	names := make([]string, 0, len(objects))
+	for _, object := range objects {
+		names = append(names, object.GetName())
+	}
+	return names
+}
+
+func TestListFolderSince(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, folder.PutObject("a", &bytes.Buffer{}))
+	assert.NoError(t, folder.PutObject("sub/b", &bytes.Buffer{}))
+
+	objects, marker, err := internal.ListFolderSince(folder, "")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "sub/b"}, getObjectNames(objects))
+	assert.NotEmpty(t, marker)
+
+	objects, sameMarker, err := internal.ListFolderSince(folder, marker)
+	assert.NoError(t, err)
+	assert.Empty(t, objects)
+	assert.Equal(t, marker, sameMarker)
+
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, folder.PutObject("sub/c", &bytes.Buffer{}))
+	assert.NoError(t, folder.PutObject("a", bytes.NewBufferString("changed")))
+
+	objects, nextMarker, err := internal.ListFolderSince(folder, marker)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "sub/c"}, getObjectNames(objects))
+	assert.NotEqual(t, marker, nextMarker)
+}
+
+// sameTimeFolder lists its top level objects as modified at the same time
+type sameTimeFolder struct {
+	storage.Folder
+	modified time.Time
+}
+
+func (folder sameTimeFolder) ListFolder() ([]storage.Object, []storage.Folder, error) {
+	objects, subFolders, err := folder.Folder.ListFolder()
+	for i, object := range objects {
+		objects[i] = storage.NewLocalObject(object.GetName(), folder.modified, object.GetSize())
+	}
+	return objects, subFolders, err
+}
+
+func TestListFolderSince_ObjectModifiedAtMarkerTime(t *testing.T) {
+	folder := sameTimeFolder{testtools.MakeDefaultInMemoryStorageFolder(), time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)}
+	assert.NoError(t, folder.PutObject("a", &bytes.Buffer{}))
+
+	objects, marker, err := internal.ListFolderSince(folder, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, getObjectNames(objects))
+
+	assert.NoError(t, folder.PutObject("b", &bytes.Buffer{}))
+	objects, marker, err = internal.ListFolderSince(folder, marker)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, getObjectNames(objects))
+
+	objects, _, err = internal.ListFolderSince(folder, marker)
+	assert.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestListFolderSince_TimeMarker(t *testing.T) {
+	modified := time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)
+	folder := sameTimeFolder{testtools.MakeDefaultInMemoryStorageFolder(), modified}
+	assert.NoError(t, folder.PutObject("a", &bytes.Buffer{}))
+
+	objects, _, err := internal.ListFolderSince(folder, modified.Format(time.RFC3339Nano))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, getObjectNames(objects))
+}
+
+func TestListFolderSince_InvalidMarker(t *testing.T) {
+	_, _, err := internal.ListFolderSince(testtools.MakeDefaultInMemoryStorageFolder(), "yesterday")
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"io"
+	"strings"
+
+	"github.com/wal-g/storages/storage"
+)
+
+// LowercaseFolder lowercases object names passed to the inner folder,
+// so names differing only in case can't collide on case-insensitive storages.
+// Listed names are returned as stored.
+type LowercaseFolder struct {
+	inner storage.Folder
+}
+
+func NewLowercaseFolder(inner storage.Folder) *LowercaseFolder {
+	return &LowercaseFolder{inner}
+}
+
+func (folder *LowercaseFolder) GetPath() string {
+	return folder.inner.GetPath()
+}
+
+func (folder *LowercaseFolder) ListFolder() (objects []storage.Object, subFolders []storage.Folder, err error) {
+	objects, innerSubFolders, err := folder.inner.ListFolder()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, subFolder := range innerSubFolders {
+		subFolders = append(subFolders, NewLowercaseFolder(subFolder))
+	}
+	return objects, subFolders, nil
+}
+
+func (folder *LowercaseFolder) DeleteObjects(objectRelativePaths []string) error {
+	lowercasePaths := make([]string, 0, len(objectRelativePaths))
+	for _, objectRelativePath := range objectRelativePaths {
+		lowercasePaths = append(lowercasePaths, strings.ToLower(objectRelativePath))
+	}
+	return folder.inner.DeleteObjects(lowercasePaths)
+}
+
+func (folder *LowercaseFolder) Exists(objectRelativePath string) (bool, error) {
+	return folder.inner.Exists(strings.ToLower(objectRelativePath))
+}
+
+func (folder *LowercaseFolder) GetSubFolder(subFolderRelativePath string) storage.Folder {
+	return NewLowercaseFolder(folder.inner.GetSubFolder(strings.ToLower(subFolderRelativePath)))
+}
+
+func (folder *LowercaseFolder) ReadObject(objectRelativePath string) (io.ReadCloser, error) {
+	return folder.inner.ReadObject(strings.ToLower(objectRelativePath))
+}
+
+func (folder *LowercaseFolder) PutObject(name string, content io.Reader) error {
+	return folder.inner.PutObject(strings.ToLower(name), content)
+}
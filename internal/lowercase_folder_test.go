@@ -0,0 +1,49 @@
+package internal_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestLowercaseFolder_ReadsRegardlessOfCase(t *testing.T) {
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	folder := internal.NewLowercaseFolder(inner)
+
+	assert.NoError(t, folder.PutObject("Basebackups_005/Backup.json", strings.NewReader("{}")))
+
+	exists, err := inner.Exists("basebackups_005/backup.json")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	for _, name := range []string{"basebackups_005/backup.json", "BASEBACKUPS_005/BACKUP.JSON", "Basebackups_005/Backup.json"} {
+		exists, err = folder.Exists(name)
+		assert.NoError(t, err)
+		assert.True(t, exists, name)
+	}
+
+	reader, err := folder.GetSubFolder("BaseBackups_005/").ReadObject("BACKUP.json")
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(content))
+}
+
+func TestLowercaseFolder_NamesDifferingInCaseCollide(t *testing.T) {
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	folder := internal.NewLowercaseFolder(inner)
+
+	assert.NoError(t, folder.PutObject("Backup", strings.NewReader("first")))
+	assert.NoError(t, folder.PutObject("backup", strings.NewReader("second")))
+	objects, _, err := inner.ListFolder()
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	assert.NoError(t, folder.DeleteObjects([]string{"BACKUP"}))
+	exists, err := inner.Exists("backup")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
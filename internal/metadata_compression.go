@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compressMetadataIfConfigured gzips JSON body of sentinel or metadata if CompressMetadataSetting is enabled
+func compressMetadataIfConfigured(body []byte) ([]byte, error) {
+	enabled, err := GetBoolSettingDefault(CompressMetadataSetting, false)
+	if err != nil || !enabled {
+		return body, err
+	}
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err = writer.Write(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compress metadata")
+	}
+	err = writer.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compress metadata")
+	}
+	return buffer.Bytes(), nil
+}
+
+// decompressMetadata gunzips sentinel or metadata body compressed by compressMetadataIfConfigured.
+// Plain JSON can't start with gzip magic bytes, so it is returned as is.
+func decompressMetadata(body []byte) ([]byte, error) {
+	if !bytes.HasPrefix(body, gzipMagic) {
+		return body, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decompress metadata")
+	}
+	defer reader.Close()
+	decompressed, err := ioutil.ReadAll(reader)
+	return decompressed, errors.Wrap(err, "failed to decompress metadata")
+}
@@ -0,0 +1,49 @@
+package internal_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/memory"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+const compressedSentinelBackupName = "base_000000010000000000000004"
+
+func uploadTestSentinel(t *testing.T, compress string) (*internal.Backup, []byte) {
+	storage := memory.NewStorage()
+	uploader := testtools.NewStoringMockUploader(storage, nil)
+	uploader.UploadingFolder = uploader.UploadingFolder.GetSubFolder(utility.BaseBackupPath)
+	withSettings(t, map[string]string{internal.CompressMetadataSetting: compress}, func() {
+		err := internal.UploadSentinel(uploader, &internal.BackupSentinelDto{PgVersion: 120000}, compressedSentinelBackupName)
+		assert.NoError(t, err)
+	})
+	baseBackupFolder := memory.NewFolder("in_memory/", storage).GetSubFolder(utility.BaseBackupPath)
+	readCloser, err := baseBackupFolder.ReadObject(internal.SentinelNameFromBackup(compressedSentinelBackupName))
+	assert.NoError(t, err)
+	rawSentinel, err := ioutil.ReadAll(readCloser)
+	assert.NoError(t, err)
+	return internal.NewBackup(baseBackupFolder, compressedSentinelBackupName), rawSentinel
+}
+
+func TestCompressMetadata_RoundTrip(t *testing.T) {
+	backup, rawSentinel := uploadTestSentinel(t, "true")
+	assert.True(t, bytes.HasPrefix(rawSentinel, []byte{0x1f, 0x8b}))
+
+	sentinel, err := backup.GetSentinel()
+	assert.NoError(t, err)
+	assert.Equal(t, 120000, sentinel.PgVersion)
+}
+
+func TestCompressMetadata_DisabledByDefault(t *testing.T) {
+	backup, rawSentinel := uploadTestSentinel(t, "false")
+	assert.True(t, bytes.HasPrefix(rawSentinel, []byte("{")))
+
+	var sentinel internal.BackupSentinelDto
+	assert.NoError(t, internal.FetchStreamSentinel(backup, &sentinel))
+	assert.Equal(t, 120000, sentinel.PgVersion)
+}
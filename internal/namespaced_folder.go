@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"io"
+	"strings"
+
+	"github.com/wal-g/storages/storage"
+)
+
+// NamespacedFolder stores all objects of the inner folder under the namespace prefix.
+// Names passed to and returned from it are relative to the namespace,
+// so callers of multi-tenant storage don't have to manage the prefix themselves.
+type NamespacedFolder struct {
+	inner     storage.Folder
+	namespace string
+}
+
+func NewNamespacedFolder(inner storage.Folder, namespace string) *NamespacedFolder {
+	return &NamespacedFolder{inner, storage.AddDelimiterToPath(strings.Trim(namespace, "/"))}
+}
+
+func (folder *NamespacedFolder) withNamespace(relativePath string) string {
+	return folder.namespace + strings.TrimPrefix(relativePath, "/")
+}
+
+func (folder *NamespacedFolder) GetPath() string {
+	return folder.inner.GetPath() + folder.namespace
+}
+
+func (folder *NamespacedFolder) ListFolder() (objects []storage.Object, subFolders []storage.Folder, err error) {
+	objects, innerSubFolders, err := folder.inner.GetSubFolder(folder.namespace).ListFolder()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, subFolder := range innerSubFolders {
+		subFolderNamespace := strings.TrimPrefix(subFolder.GetPath(), folder.inner.GetPath())
+		subFolders = append(subFolders, NewNamespacedFolder(folder.inner, subFolderNamespace))
+	}
+	return objects, subFolders, nil
+}
+
+func (folder *NamespacedFolder) DeleteObjects(objectRelativePaths []string) error {
+	namespacedPaths := make([]string, 0, len(objectRelativePaths))
+	for _, objectRelativePath := range objectRelativePaths {
+		namespacedPaths = append(namespacedPaths, folder.withNamespace(objectRelativePath))
+	}
+	return folder.inner.DeleteObjects(namespacedPaths)
+}
+
+func (folder *NamespacedFolder) Exists(objectRelativePath string) (bool, error) {
+	return folder.inner.Exists(folder.withNamespace(objectRelativePath))
+}
+
+func (folder *NamespacedFolder) GetSubFolder(subFolderRelativePath string) storage.Folder {
+	return NewNamespacedFolder(folder.inner, folder.withNamespace(subFolderRelativePath))
+}
+
+func (folder *NamespacedFolder) ReadObject(objectRelativePath string) (io.ReadCloser, error) {
+	return folder.inner.ReadObject(folder.withNamespace(objectRelativePath))
+}
+
+func (folder *NamespacedFolder) PutObject(name string, content io.Reader) error {
+	return folder.inner.PutObject(folder.withNamespace(name), content)
+}
@@ -0,0 +1,54 @@
+package internal_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestNamespacedFolder_StoresUnderNamespace(t *testing.T) {
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	folder := internal.NewNamespacedFolder(inner, "tenant1")
+
+	err := folder.PutObject("basebackups_005/sentinel.json", strings.NewReader("{}"))
+	assert.NoError(t, err)
+
+	exists, err := inner.Exists("tenant1/basebackups_005/sentinel.json")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = folder.Exists("basebackups_005/sentinel.json")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	reader, err := folder.GetSubFolder("basebackups_005").ReadObject("sentinel.json")
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(content))
+}
+
+func TestNamespacedFolder_ListsWithoutNamespace(t *testing.T) {
+	inner := testtools.MakeDefaultInMemoryStorageFolder()
+	assert.NoError(t, inner.PutObject("tenant1/a", strings.NewReader("a")))
+	assert.NoError(t, inner.PutObject("tenant1/sub/b", strings.NewReader("b")))
+	assert.NoError(t, inner.PutObject("tenant2/c", strings.NewReader("c")))
+	folder := internal.NewNamespacedFolder(inner, "/tenant1/")
+
+	objects, err := storage.ListFolderRecursively(folder)
+	assert.NoError(t, err)
+	names := make([]string, 0)
+	for _, object := range objects {
+		names = append(names, object.GetName())
+	}
+	assert.ElementsMatch(t, []string{"a", "sub/b"}, names)
+
+	assert.NoError(t, folder.DeleteObjects([]string{"sub/b"}))
+	exists, err := inner.Exists("tenant1/sub/b")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
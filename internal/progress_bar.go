@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wal-g/wal-g/internal/ioextensions"
+	"github.com/wal-g/wal-g/utility"
+)
+
+const (
+	progressBarWidth          = 30
+	progressBarRenderInterval = 200 * time.Millisecond
+)
+
+// ProgressBar renders count of processed objects and bytes to interactive output.
+// All methods are safe to call on nil ProgressBar, which means that progress is disabled.
+type ProgressBar struct {
+	output       io.Writer
+	totalObjects int64
+	totalBytes   int64
+	doneObjects  int64
+	doneBytes    int64
+
+	mutex        sync.Mutex
+	lastRendered time.Time
+}
+
+// NewProgressBar returns progress bar writing to output, or nil if progress should not be shown:
+// it is shown only when output is a terminal, unless force is set; noProgress always disables it
+func NewProgressBar(output io.Writer, noProgress, force bool, totalObjects, totalBytes int64) *ProgressBar {
+	if noProgress || !(force || isTerminal(output)) {
+		return nil
+	}
+	return &ProgressBar{output: output, totalObjects: totalObjects, totalBytes: totalBytes}
+}
+
+func isTerminal(output io.Writer) bool {
+	file, ok := output.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := file.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// AddBytes accounts n processed bytes
+func (bar *ProgressBar) AddBytes(n int64) {
+	if bar == nil {
+		return
+	}
+	atomic.AddInt64(&bar.doneBytes, n)
+	bar.render(false)
+}
+
+// AddTotal adds objects and bytes to process, for operations which find them gradually
+func (bar *ProgressBar) AddTotal(objects, bytes int64) {
+	if bar == nil {
+		return
+	}
+	atomic.AddInt64(&bar.totalObjects, objects)
+	atomic.AddInt64(&bar.totalBytes, bytes)
+	bar.render(true)
+}
+
+// AddObject accounts one processed object
+func (bar *ProgressBar) AddObject() {
+	if bar == nil {
+		return
+	}
+	atomic.AddInt64(&bar.doneObjects, 1)
+	bar.render(true)
+}
+
+// Finish renders final state and moves output to the next line
+func (bar *ProgressBar) Finish() {
+	if bar == nil {
+		return
+	}
+	bar.render(true)
+	bar.mutex.Lock()
+	defer bar.mutex.Unlock()
+	_, _ = fmt.Fprintln(bar.output)
+}
+
+// NewReader returns reader which accounts all bytes read through it
+func (bar *ProgressBar) NewReader(reader io.Reader) io.Reader {
+	if bar == nil {
+		return reader
+	}
+	return &progressReader{reader, bar}
+}
+
+func (bar *ProgressBar) render(force bool) {
+	bar.mutex.Lock()
+	defer bar.mutex.Unlock()
+	if !force && time.Since(bar.lastRendered) < progressBarRenderInterval {
+		return
+	}
+	bar.lastRendered = time.Now()
+
+	doneObjects := atomic.LoadInt64(&bar.doneObjects)
+	doneBytes := atomic.LoadInt64(&bar.doneBytes)
+	totalObjects := atomic.LoadInt64(&bar.totalObjects)
+	totalBytes := atomic.LoadInt64(&bar.totalBytes)
+	filled := 0
+	if totalBytes > 0 {
+		filled = int(int64(progressBarWidth) * doneBytes / totalBytes)
+	} else if totalObjects > 0 {
+		filled = int(int64(progressBarWidth) * doneObjects / totalObjects)
+	}
+	filled = utility.Min(filled, progressBarWidth)
+	// total size is unknown when objects are read without listing
+	bytesProgress := fmt.Sprintf("%d bytes", doneBytes)
+	if totalBytes > 0 {
+		bytesProgress = fmt.Sprintf("%d/%d bytes", doneBytes, totalBytes)
+	}
+	_, _ = fmt.Fprintf(bar.output, "\r[%s%s] %d/%d objects, %s",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled),
+		doneObjects, totalObjects, bytesProgress)
+}
+
+type progressReader struct {
+	io.Reader
+	bar *ProgressBar
+}
+
+func (reader *progressReader) Read(p []byte) (n int, err error) {
+	n, err = reader.Reader.Read(p)
+	reader.bar.AddBytes(int64(n))
+	return
+}
+
+// progressReaderMaker accounts bytes read by readers of ReaderMaker in bar
+type progressReaderMaker struct {
+	ReaderMaker
+	bar *ProgressBar
+}
+
+func (readerMaker progressReaderMaker) Reader() (io.ReadCloser, error) {
+	readCloser, err := readerMaker.ReaderMaker.Reader()
+	if err != nil || readerMaker.bar == nil {
+		return readCloser, err
+	}
+	return ioextensions.ReadCascadeCloser{Reader: readerMaker.bar.NewReader(readCloser), Closer: readCloser}, nil
+}
@@ -0,0 +1,75 @@
+package internal_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestNewProgressBar_DisabledForNonTerminal(t *testing.T) {
+	var output bytes.Buffer
+	progressBar := internal.NewProgressBar(&output, false, false, 1, 1)
+	assert.Nil(t, progressBar)
+
+	progressBar.AddBytes(1)
+	progressBar.AddObject()
+	progressBar.Finish()
+	assert.Empty(t, output.String())
+}
+
+func TestNewProgressBar_NoProgressOverridesForce(t *testing.T) {
+	var output bytes.Buffer
+	assert.Nil(t, internal.NewProgressBar(&output, true, true, 1, 1))
+}
+
+func TestProgressBar_ForcedOnNonTerminal(t *testing.T) {
+	var output bytes.Buffer
+	progressBar := internal.NewProgressBar(&output, false, true, 2, 10)
+	assert.NotNil(t, progressBar)
+
+	progressBar.AddBytes(10)
+	progressBar.AddObject()
+	progressBar.AddObject()
+	progressBar.Finish()
+	assert.Contains(t, output.String(), "2/2 objects, 10/10 bytes")
+	assert.True(t, strings.HasSuffix(output.String(), "\n"))
+}
+
+func TestStartCopy_ReportsProgress(t *testing.T) {
+	var from = testtools.CreateMockStorageFolderWithPermanentBackups(t)
+	var to = testtools.MakeDefaultInMemoryStorageFolder()
+	infos, err := internal.GetAllCopyingInfo(from, to)
+	assert.NoError(t, err)
+
+	var output bytes.Buffer
+	progressBar := internal.NewProgressBar(&output, false, true, int64(len(infos)), 0)
+	isSuccess, err := internal.StartCopy(infos, internal.CopyWithProgressBar(progressBar))
+	assert.NoError(t, err)
+	assert.True(t, isSuccess)
+	progressBar.Finish()
+
+	assert.Contains(t, output.String(), "objects")
+	assert.Contains(t, output.String(), strings.Repeat("=", 30))
+}
+
+func TestExtractAll_ReportsFetchProgress(t *testing.T) {
+	member := &bytes.Buffer{}
+	testtools.CreateTar(member, &io.LimitedReader{R: testtools.NewStrideByteReader(10), N: 1024})
+	tarSize := member.Len()
+	var output bytes.Buffer
+	internal.FetchProgressBar = internal.NewProgressBar(&output, false, true, 0, 0)
+	defer func() { internal.FetchProgressBar = nil }()
+
+	err := internal.ExtractAll(&testtools.BufferTarInterpreter{},
+		[]internal.ReaderMaker{&BufferReaderMaker{member, "/usr/local/file.tar"}})
+	assert.NoError(t, err)
+	internal.FetchProgressBar.Finish()
+
+	assert.Contains(t, output.String(), fmt.Sprintf("1/1 objects, %d bytes", tarSize))
+}
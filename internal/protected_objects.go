@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// DefaultProtectedObjects are control objects of WAL-G which bulk copy and delete leave alone
+var DefaultProtectedObjects = []string{CopySuccessMarkerName, StorageLockObjectName}
+
+// getProtectedObjectGlobs returns comma separated globs of ProtectedObjectsSetting or DefaultProtectedObjects
+func getProtectedObjectGlobs() ([]string, error) {
+	value, ok := GetSetting(ProtectedObjectsSetting)
+	if !ok {
+		return DefaultProtectedObjects, nil
+	}
+	globs := make([]string, 0)
+	for _, glob := range strings.Split(value, ",") {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, errors.Errorf("%s contains invalid glob '%s'", ProtectedObjectsSetting, glob)
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// IsProtectedObject reports whether the whole name or its last element matches one of globs
+func IsProtectedObject(name string, globs []string) bool {
+	for _, glob := range globs {
+		if matched, _ := path.Match(glob, name); matched {
+			return true
+		}
+		if matched, _ := path.Match(glob, path.Base(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterProtectedObjects skips objects matching globs
+func FilterProtectedObjects(objects []storage.Object, globs []string) []storage.Object {
+	filtered := make([]storage.Object, 0, len(objects))
+	for _, object := range objects {
+		if IsProtectedObject(object.GetName(), globs) {
+			tracelog.DebugLogger.Printf("Skipping protected object '%s'", object.GetName())
+			continue
+		}
+		filtered = append(filtered, object)
+	}
+	return filtered
+}
+
+// FilterProtectedCopyingInfos skips infos of objects matching globs
+func FilterProtectedCopyingInfos(infos []CopyingInfo, globs []string) []CopyingInfo {
+	filtered := make([]CopyingInfo, 0, len(infos))
+	for _, info := range infos {
+		if IsProtectedObject(info.Object.GetName(), globs) {
+			tracelog.DebugLogger.Printf("Skipping protected object '%s'", info.Object.GetName())
+			continue
+		}
+		filtered = append(filtered, info)
+	}
+	return filtered
+}
@@ -0,0 +1,62 @@
+package internal_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func makeFolderWithProtectedObjects(t *testing.T) storage.Folder {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	for _, name := range []string{"wal_005/000000010000000000000001.lz4", internal.CopySuccessMarkerName,
+		internal.StorageLockObjectName, "catalog/catalog.lock"} {
+		require.NoError(t, folder.PutObject(name, strings.NewReader(name)))
+	}
+	return folder
+}
+
+func TestIsProtectedObject(t *testing.T) {
+	globs := []string{"_SUCCESS", "*.lock"}
+	assert.True(t, internal.IsProtectedObject("_SUCCESS", globs))
+	assert.True(t, internal.IsProtectedObject("prod/_SUCCESS", globs))
+	assert.True(t, internal.IsProtectedObject("catalog/catalog.lock", globs))
+	assert.False(t, internal.IsProtectedObject("wal_005/000000010000000000000001.lz4", globs))
+}
+
+func TestSyncFolder_SkipsProtectedObjects(t *testing.T) {
+	withSettings(t, map[string]string{internal.ProtectedObjectsSetting: "_SUCCESS, *.lock, walg_storage_lock.json"}, func() {
+		from := makeFolderWithProtectedObjects(t)
+		to := testtools.MakeDefaultInMemoryStorageFolder()
+		require.NoError(t, to.PutObject("old/catalog.lock", strings.NewReader("lock")))
+		require.NoError(t, to.PutObject("old/extraneous", strings.NewReader("extraneous")))
+
+		require.NoError(t, internal.SyncFolder(from, to, true))
+
+		assert.ElementsMatch(t, []string{"wal_005/000000010000000000000001.lz4", "old/catalog.lock"},
+			getFolderObjectNames(t, to))
+	})
+}
+
+func TestDeleteEverything_KeepsProtectedObjects(t *testing.T) {
+	withSettings(t, map[string]string{internal.ProtectedObjectsSetting: "_SUCCESS,*.lock"}, func() {
+		folder := makeFolderWithProtectedObjects(t)
+
+		internal.DeleteEverything(folder, true, false, []string{})
+
+		assert.ElementsMatch(t, []string{internal.CopySuccessMarkerName, "catalog/catalog.lock"},
+			getFolderObjectNames(t, folder))
+	})
+}
+
+func TestValidateSettings_InvalidProtectedObjectGlob(t *testing.T) {
+	withSettings(t, map[string]string{internal.ProtectedObjectsSetting: "[broken"}, func() {
+		err := internal.ValidateSettings()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), internal.ProtectedObjectsSetting)
+	})
+}
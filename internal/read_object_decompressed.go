@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"io"
+
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal/compression"
+	"github.com/wal-g/wal-g/utility"
+)
+
+// ReadObjectDecompressed reads object of folder and decompresses it with the codec of its extension,
+// objects with unknown or no codec extension are returned as is.
+// WAL-G names compressed objects by their codec, so the extension plays the role of codec metadata here.
+func ReadObjectDecompressed(folder storage.Folder, objectPath string) (io.ReadCloser, error) {
+	archiveReader, err := folder.ReadObject(objectPath)
+	if err != nil {
+		return nil, err
+	}
+	decompressor := compression.FindDecompressor(utility.GetFileExtension(objectPath))
+	if decompressor == nil {
+		return archiveReader, nil
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		defer utility.LoggedClose(archiveReader, "")
+		err := DecompressDecryptBytes(&EmptyWriteIgnorer{writer}, archiveReader, decompressor)
+		_ = writer.CloseWithError(err)
+	}()
+	return reader, nil
+}
@@ -0,0 +1,58 @@
+package internal_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/internal/compression"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func TestReadObjectDecompressed_DecompressesKnownCodecs(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	data := []byte(strings.Repeat("wal-g", 1000))
+	for _, algorithm := range compression.CompressingAlgorithms {
+		compressor := compression.Compressors[algorithm]
+		var compressed bytes.Buffer
+		writer := compressor.NewWriter(&compressed)
+		_, err := writer.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		name := "object." + compressor.FileExtension()
+		require.NoError(t, folder.PutObject(name, &compressed))
+
+		reader, err := internal.ReadObjectDecompressed(folder, name)
+		require.NoError(t, err)
+		actual, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err, algorithm)
+		assert.NoError(t, reader.Close())
+		assert.Equal(t, data, actual, algorithm)
+	}
+}
+
+func TestReadObjectDecompressed_PassesThroughUnknownCodec(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	for _, name := range []string{"object.txt", "object"} {
+		require.NoError(t, folder.PutObject(name, strings.NewReader("plain")))
+
+		reader, err := internal.ReadObjectDecompressed(folder, name)
+		require.NoError(t, err)
+		actual, err := ioutil.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.NoError(t, reader.Close())
+		assert.Equal(t, "plain", string(actual), name)
+	}
+}
+
+func TestReadObjectDecompressed_MissingObject(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+
+	_, err := internal.ReadObjectDecompressed(folder, "missing.lz4")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/utility"
+)
+
+// EstimateRestore sums tar partitions of the backup and of every backup it is incremented from,
+// since all of them are downloaded during restore, and estimates download duration at throughput.
+func EstimateRestore(rootFolder storage.Folder, backupName string,
+	throughputBytesPerSec int64) (objects int64, size int64, eta time.Duration, err error) {
+	if throughputBytesPerSec <= 0 {
+		return 0, 0, 0, errors.Errorf("throughput should be positive, but is %d", throughputBytesPerSec)
+	}
+	backup, err := GetBackupByName(backupName, utility.BaseBackupPath, rootFolder)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for {
+		tarObjects, err := storage.ListFolderRecursively(backup.getTarPartitionFolder())
+		if err != nil {
+			return 0, 0, 0, errors.Wrapf(err, "failed to list backup '%s'", backup.Name)
+		}
+		for _, object := range tarObjects {
+			objects++
+			size += object.GetSize()
+		}
+		sentinel, err := backup.GetSentinel()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		if !sentinel.IsIncremental() {
+			break
+		}
+		tracelog.DebugLogger.Printf("Backup '%s' is incremented from '%s'\n", backup.Name, *sentinel.IncrementFrom)
+		backup = NewBackup(backup.BaseBackupFolder, *sentinel.IncrementFrom)
+	}
+	eta = time.Duration(float64(size) / float64(throughputBytesPerSec) * float64(time.Second))
+	return objects, size, eta, nil
+}
@@ -0,0 +1,59 @@
+package internal_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+const (
+	estimatedFullBackupName  = "base_000000010000000000000003"
+	estimatedDeltaBackupName = "base_000000010000000000000005_D_000000010000000000000003"
+)
+
+func putEstimatedBackup(t *testing.T, baseBackupFolder storage.Folder, backupName, sentinel string, tarSizes ...int) {
+	assert.NoError(t, baseBackupFolder.PutObject(backupName+utility.SentinelSuffix, strings.NewReader(sentinel)))
+	for i, tarSize := range tarSizes {
+		tarName := backupName + internal.TarPartitionFolderName + "part_" + strconv.Itoa(i+1) + ".tar.lz4"
+		assert.NoError(t, baseBackupFolder.PutObject(tarName, bytes.NewReader(make([]byte, tarSize))))
+	}
+}
+
+func prepareEstimatedBackups(t *testing.T) storage.Folder {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	baseBackupFolder := folder.GetSubFolder(utility.BaseBackupPath)
+	putEstimatedBackup(t, baseBackupFolder, estimatedFullBackupName, "{}", 1000, 3000)
+	putEstimatedBackup(t, baseBackupFolder, estimatedDeltaBackupName,
+		`{"DeltaFrom":"`+estimatedFullBackupName+`","DeltaFullName":"`+estimatedFullBackupName+
+			`","DeltaFromLSN":0,"DeltaCount":1}`, 1000)
+	return folder
+}
+
+func TestEstimateRestore_FullBackup(t *testing.T) {
+	objects, size, eta, err := internal.EstimateRestore(prepareEstimatedBackups(t), estimatedFullBackupName, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), objects)
+	assert.Equal(t, int64(4000), size)
+	assert.Equal(t, 4*time.Second, eta)
+}
+
+func TestEstimateRestore_DeltaBackupIncludesBase(t *testing.T) {
+	objects, size, eta, err := internal.EstimateRestore(prepareEstimatedBackups(t), estimatedDeltaBackupName, 2000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), objects)
+	assert.Equal(t, int64(5000), size)
+	assert.Equal(t, 2500*time.Millisecond, eta)
+}
+
+func TestEstimateRestore_InvalidThroughput(t *testing.T) {
+	_, _, _, err := internal.EstimateRestore(prepareEstimatedBackups(t), estimatedFullBackupName, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const redactedSettingValue = "<redacted>"
+
+var secretSettingSuffixes = []string{"PASSWORD", "PASSPHRASE", "SECRET_ACCESS_KEY", "TOKEN", "_KEY"}
+
+// secretSettings contain credentials although their names don't look like it
+var secretSettings = map[string]bool{
+	MongoDBUriSetting:          true,
+	MysqlDatasourceNameSetting: true,
+	SQLServerConnectionString:  true,
+}
+
+func isSecretSetting(setting string) bool {
+	if secretSettings[setting] {
+		return true
+	}
+	for _, suffix := range secretSettingSuffixes {
+		if strings.HasSuffix(setting, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetEffectiveSettings returns values of all set settings as WAL-G sees them
+// after merging flags, environment, config file and defaults. Secrets are redacted.
+func GetEffectiveSettings() map[string]string {
+	settings := make(map[string]string)
+	for setting := range AllowedSettings {
+		value, ok := GetSetting(setting)
+		if !ok {
+			continue
+		}
+		if isSecretSetting(setting) && value != "" {
+			value = redactedSettingValue
+		}
+		settings[setting] = value
+	}
+	return settings
+}
+
+// getConfiguredStoragePrefix returns prefix setting of the storage which ConfigureFolder would use
+func getConfiguredStoragePrefix() (prefixSetting string, prefix string, ok bool) {
+	for _, adapter := range StorageAdapters {
+		prefix, ok := getWaleCompatibleSettingFrom(adapter.prefixName, viper.GetViper())
+		if ok {
+			return "WALG_" + adapter.prefixName, prefix, true
+		}
+	}
+	return "", "", false
+}
+
+// HandleShowConfig prints effective settings sorted by name
+func HandleShowConfig(output io.Writer) error {
+	prefixSetting, prefix, ok := getConfiguredStoragePrefix()
+	if ok {
+		_, err := fmt.Fprintf(output, "# storage: %s=%s\n", prefixSetting, prefix)
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err := fmt.Fprintln(output, "# storage: not configured")
+		if err != nil {
+			return err
+		}
+	}
+
+	settings := GetEffectiveSettings()
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		_, err := fmt.Fprintf(output, "%s=%s\n", name, settings[name])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// MinStartupRetryWait and MaxStartupRetryWait bound waits between attempts to configure storage folder
+var MinStartupRetryWait = time.Second
+var MaxStartupRetryWait = 30 * time.Second
+
+func getStartupRetries() (int, error) {
+	value, ok := GetSetting(StartupRetriesSetting)
+	if !ok {
+		return 0, nil
+	}
+	retries, err := strconv.Atoi(value)
+	if err != nil || retries < 0 {
+		return 0, errors.Errorf("%s should be a non-negative integer, but is '%s'", StartupRetriesSetting, value)
+	}
+	return retries, nil
+}
+
+// configureFolderWithRetries repeats configure up to StartupRetriesSetting times
+// while it fails with network errors, so that a brief DNS or TLS failure doesn't abort the command.
+// Other errors, e.g. authentication ones, are returned immediately.
+func configureFolderWithRetries(configure func() (storage.Folder, error)) (storage.Folder, error) {
+	retries, err := getStartupRetries()
+	if err != nil {
+		return nil, err
+	}
+	retrier := newExponentialRetrier(MinStartupRetryWait, MaxStartupRetryWait)
+	for i := 0; ; i++ {
+		folder, err := configure()
+		if err == nil || i >= retries || !isTransientNetworkError(err) {
+			return folder, err
+		}
+		tracelog.WarningLogger.Printf("Failed to configure storage: %v, retrying (%d/%d)\n", err, i+1, retries)
+		retrier.retry()
+	}
+}
+
+// isTransientNetworkError walks the error chain including aws-sdk errors, which keep the cause in OrigErr
+// instead of Unwrap, e.g. a DNS error of GetBucketLocation at S3 folder construction.
+// Certificate errors and 4xx responses anywhere in the chain are not transient,
+// even though the url.Error wrapping them is a net.Error.
+func isTransientNetworkError(err error) bool {
+	isTransient := false
+	for ; err != nil; err = unwrapStartupError(err) {
+		if requestFailure, ok := err.(awserr.RequestFailure); ok {
+			if requestFailure.StatusCode() < http.StatusInternalServerError {
+				return false
+			}
+			isTransient = true
+		}
+		switch err.(type) {
+		case x509.UnknownAuthorityError, x509.CertificateInvalidError, x509.HostnameError:
+			return false
+		case net.Error, tls.RecordHeaderError:
+			isTransient = true
+		}
+	}
+	return isTransient
+}
+
+func unwrapStartupError(err error) error {
+	if awsError, ok := err.(awserr.Error); ok {
+		return awsError.OrigErr()
+	}
+	return errors.Unwrap(err)
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"crypto/x509"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/memory"
+	"github.com/wal-g/storages/storage"
+)
+
+func configureWithFailures(t *testing.T, retries string, failures []error) (storage.Folder, int, error) {
+	minWait, maxWait := MinStartupRetryWait, MaxStartupRetryWait
+	MinStartupRetryWait, MaxStartupRetryWait = time.Millisecond, time.Millisecond
+	viper.Set(StartupRetriesSetting, retries)
+	defer func() {
+		MinStartupRetryWait, MaxStartupRetryWait = minWait, maxWait
+		viper.Set(StartupRetriesSetting, nil)
+	}()
+
+	attempts := 0
+	folder, err := configureFolderWithRetries(func() (storage.Folder, error) {
+		attempts++
+		if attempts <= len(failures) {
+			return nil, failures[attempts-1]
+		}
+		return memory.NewFolder("in_memory/", memory.NewStorage()), nil
+	})
+	return folder, attempts, err
+}
+
+func TestConfigureFolderWithRetries_RetriesNetworkError(t *testing.T) {
+	dnsError := errors.Wrap(&net.DNSError{Err: "no such host", Name: "storage.example", IsTemporary: true}, "failed to connect")
+
+	folder, attempts, err := configureWithFailures(t, "3", []error{dnsError, dnsError})
+	assert.NoError(t, err)
+	assert.NotNil(t, folder)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestConfigureFolderWithRetries_RetriesAwsRequestError(t *testing.T) {
+	dnsError := &net.DNSError{Err: "no such host", Name: "bucket.s3.amazonaws.com", IsTemporary: true}
+	requestError := errors.Wrap(awserr.New(request.ErrCodeRequestError, "send request failed", dnsError),
+		"failed to get AWS region for bucket")
+
+	folder, attempts, err := configureWithFailures(t, "3", []error{requestError})
+	assert.NoError(t, err)
+	assert.NotNil(t, folder)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestConfigureFolderWithRetries_DoesNotRetryAwsClientError(t *testing.T) {
+	forbidden := awserr.NewRequestFailure(awserr.New("AccessDenied", "Access Denied", nil), 403, "request-id")
+
+	_, attempts, err := configureWithFailures(t, "3", []error{errors.Wrap(forbidden, "failed to get AWS region for bucket")})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestConfigureFolderWithRetries_GivesUp(t *testing.T) {
+	dnsError := &net.DNSError{Err: "no such host", Name: "storage.example"}
+
+	_, attempts, err := configureWithFailures(t, "1", []error{dnsError, dnsError, dnsError})
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestConfigureFolderWithRetries_DoesNotRetryAuthError(t *testing.T) {
+	_, attempts, err := configureWithFailures(t, "3", []error{errors.New("401 Unauthorized")})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestConfigureFolderWithRetries_NoRetriesByDefault(t *testing.T) {
+	_, attempts, err := configureWithFailures(t, "0", []error{&net.DNSError{Err: "no such host"}})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestConfigureFolderWithRetries_DoesNotRetryCertificateError(t *testing.T) {
+	certificateError := &url.Error{Op: "Get", URL: "https://storage.example", Err: x509.UnknownAuthorityError{}}
+
+	_, attempts, err := configureWithFailures(t, "3", []error{awserr.New(request.ErrCodeRequestError, "send request failed", certificateError)})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
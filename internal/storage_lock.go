@@ -0,0 +1,230 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+const (
+	StorageLockObjectName = "walg_storage_lock.json"
+	BreakStaleLockFlag    = "break-stale-lock"
+	// BreakStaleLockDescription is the description of BreakStaleLockFlag of commands which take the lock
+	BreakStaleLockDescription = "Take over storage lock which expired because its owner stopped without releasing it"
+	// storageLockRenewals is how many times the lock is renewed during its TTL
+	storageLockRenewals = 3
+)
+
+type StorageLockedError struct {
+	error
+}
+
+func newStorageLockedError(lock StorageLockDto) StorageLockedError {
+	return StorageLockedError{errors.Errorf("storage is locked by '%s' for %s until %s",
+		lock.Owner, lock.Operation, lock.ExpiresAt.Format(time.RFC3339))}
+}
+
+func (err StorageLockedError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+type StaleStorageLockError struct {
+	error
+}
+
+func newStaleStorageLockError(lock StorageLockDto) StaleStorageLockError {
+	return StaleStorageLockError{errors.Errorf("storage lock of '%s' for %s expired at %s without being released, "+
+		"make sure that process is stopped and use --%s", lock.Owner, lock.Operation,
+		lock.ExpiresAt.Format(time.RFC3339), BreakStaleLockFlag)}
+}
+
+func (err StaleStorageLockError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// StorageLockDto is the content of the lock object
+type StorageLockDto struct {
+	Owner      string    `json:"owner"`
+	Operation  string    `json:"operation"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// StorageLock is an advisory lock which prevents backups and deletes from running at the same time.
+// Storages have no conditional put, so the lock is best-effort: it is written
+// and then read back to detect a concurrent writer, which narrows the race but does not close it.
+// The owner renews the lock while the operation runs, so an expired lock belongs to a process
+// which died without releasing it. It is taken over only when the operator breaks it explicitly.
+type StorageLock struct {
+	folder storage.Folder
+	dto    StorageLockDto
+	ttl    time.Duration
+
+	stopRenewal chan struct{}
+	renewalDone sync.WaitGroup
+}
+
+// WithConfiguredStorageLock runs action holding the lock if WALG_STORAGE_LOCK_TTL is set and just runs it otherwise.
+// The lock is renewed while action runs and released when it returns, even with an error.
+// breakStale allows to take over an expired lock, see AcquireStorageLock.
+func WithConfiguredStorageLock(folder storage.Folder, operation string, breakStale bool, action func() error) error {
+	if _, ok := GetSetting(StorageLockTTLSetting); !ok {
+		return action()
+	}
+	ttl, err := GetDurationSetting(StorageLockTTLSetting)
+	if err != nil {
+		return err
+	}
+	lock, err := AcquireStorageLock(folder, operation, ttl, breakStale)
+	if err != nil {
+		return err
+	}
+	lock.startRenewal(ttl / storageLockRenewals)
+	err = action()
+	releaseErr := lock.Release()
+	if err != nil {
+		tracelog.ErrorLogger.PrintOnError(releaseErr)
+		return err
+	}
+	return releaseErr
+}
+
+// AcquireStorageLock fails if the storage is locked. An expired lock is taken over only with breakStale,
+// otherwise StaleStorageLockError is returned, so the operator checks that its owner is really stopped.
+func AcquireStorageLock(folder storage.Folder, operation string, ttl time.Duration, breakStale bool) (*StorageLock, error) {
+	current, exists, err := readStorageLock(folder)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if exists {
+		if now.Before(current.ExpiresAt) {
+			return nil, newStorageLockedError(current)
+		}
+		if !breakStale {
+			return nil, newStaleStorageLockError(current)
+		}
+		tracelog.WarningLogger.Printf("Breaking storage lock of '%s' for %s which expired at %s\n",
+			current.Owner, current.Operation, current.ExpiresAt.Format(time.RFC3339))
+	}
+
+	hostname, _ := os.Hostname()
+	lock := &StorageLock{folder: folder, ttl: ttl, dto: StorageLockDto{
+		Owner:      fmt.Sprintf("%s:%d:%d", hostname, os.Getpid(), now.UnixNano()),
+		Operation:  operation,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}}
+	err = lock.write()
+	if err != nil {
+		return nil, err
+	}
+
+	written, exists, err := readStorageLock(folder)
+	if err != nil {
+		return nil, err
+	}
+	if !exists || written.Owner != lock.dto.Owner {
+		return nil, newStorageLockedError(written)
+	}
+	tracelog.InfoLogger.Printf("Acquired storage lock for %s until %s\n", operation, lock.dto.ExpiresAt.Format(time.RFC3339))
+	return lock, nil
+}
+
+func (lock *StorageLock) write() error {
+	lockBytes, err := json.Marshal(lock.dto)
+	if err != nil {
+		return err
+	}
+	err = lock.folder.PutObject(StorageLockObjectName, bytes.NewReader(lockBytes))
+	return errors.Wrap(err, "failed to write storage lock")
+}
+
+// startRenewal moves ExpiresAt of the lock one TTL ahead every interval until Release
+func (lock *StorageLock) startRenewal(interval time.Duration) {
+	lock.stopRenewal = make(chan struct{})
+	lock.renewalDone.Add(1)
+	go func() {
+		defer lock.renewalDone.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if !lock.renew() {
+					return
+				}
+			case <-lock.stopRenewal:
+				return
+			}
+		}
+	}()
+}
+
+// renew reports whether the lock is still owned, failed writes are retried on the next tick
+func (lock *StorageLock) renew() bool {
+	current, exists, err := readStorageLock(lock.folder)
+	if err != nil {
+		tracelog.WarningLogger.Printf("Failed to renew storage lock: %v\n", err)
+		return true
+	}
+	if !exists || current.Owner != lock.dto.Owner {
+		tracelog.WarningLogger.Printf("Storage lock for %s was removed or taken over, not renewing it\n", lock.dto.Operation)
+		return false
+	}
+	lock.dto.ExpiresAt = time.Now().Add(lock.ttl)
+	if err = lock.write(); err != nil {
+		tracelog.WarningLogger.Printf("Failed to renew storage lock: %v\n", err)
+	}
+	return true
+}
+
+// Release stops renewal and deletes the lock object if it is still owned by this lock
+func (lock *StorageLock) Release() error {
+	if lock == nil {
+		return nil
+	}
+	if lock.stopRenewal != nil {
+		close(lock.stopRenewal)
+		lock.renewalDone.Wait()
+		lock.stopRenewal = nil
+	}
+	current, exists, err := readStorageLock(lock.folder)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	if current.Owner != lock.dto.Owner {
+		tracelog.WarningLogger.Printf("Storage lock for %s was taken over by '%s', not releasing it\n",
+			lock.dto.Operation, current.Owner)
+		return nil
+	}
+	return lock.folder.DeleteObjects([]string{StorageLockObjectName})
+}
+
+func readStorageLock(folder storage.Folder) (lock StorageLockDto, exists bool, err error) {
+	reader, err := folder.ReadObject(StorageLockObjectName)
+	if _, ok := err.(storage.ObjectNotFoundError); ok {
+		return lock, false, nil
+	}
+	if err != nil {
+		return lock, false, errors.Wrap(err, "failed to read storage lock")
+	}
+	defer reader.Close()
+	lockBytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return lock, false, errors.Wrap(err, "failed to read storage lock")
+	}
+	err = json.Unmarshal(lockBytes, &lock)
+	return lock, true, errors.Wrap(err, "failed to unmarshal storage lock")
+}
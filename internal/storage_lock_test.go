@@ -0,0 +1,123 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func putStorageLock(t *testing.T, folder storage.Folder, expiresAt time.Time) {
+	lockBytes, err := json.Marshal(internal.StorageLockDto{Owner: "other", Operation: "delete", ExpiresAt: expiresAt})
+	assert.NoError(t, err)
+	assert.NoError(t, folder.PutObject(internal.StorageLockObjectName, bytes.NewReader(lockBytes)))
+}
+
+func TestAcquireStorageLock_AcquireAndRelease(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	lock, err := internal.AcquireStorageLock(folder, "delete", time.Hour, false)
+	assert.NoError(t, err)
+	exists, err := folder.Exists(internal.StorageLockObjectName)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.NoError(t, lock.Release())
+	exists, err = folder.Exists(internal.StorageLockObjectName)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestAcquireStorageLock_Contention(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	lock, err := internal.AcquireStorageLock(folder, "backup-push", time.Hour, false)
+	assert.NoError(t, err)
+
+	_, err = internal.AcquireStorageLock(folder, "delete", time.Hour, false)
+	assert.IsType(t, internal.StorageLockedError{}, err)
+
+	assert.NoError(t, lock.Release())
+	_, err = internal.AcquireStorageLock(folder, "delete", time.Hour, false)
+	assert.NoError(t, err)
+}
+
+func TestAcquireStorageLock_ExpiredLockIsNotBrokenByDefault(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	putStorageLock(t, folder, time.Now().Add(-time.Minute))
+
+	_, err := internal.AcquireStorageLock(folder, "delete", time.Hour, false)
+	assert.IsType(t, internal.StaleStorageLockError{}, err)
+}
+
+func TestAcquireStorageLock_BreaksExpiredLock(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	putStorageLock(t, folder, time.Now().Add(-time.Minute))
+
+	lock, err := internal.AcquireStorageLock(folder, "delete", time.Hour, true)
+	assert.NoError(t, err)
+	assert.NotNil(t, lock)
+}
+
+func TestAcquireStorageLock_DoesNotBreakActiveLock(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	putStorageLock(t, folder, time.Now().Add(time.Hour))
+
+	_, err := internal.AcquireStorageLock(folder, "delete", time.Hour, true)
+	assert.IsType(t, internal.StorageLockedError{}, err)
+}
+
+func TestStorageLock_ReleaseDoesNotDeleteForeignLock(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	lock, err := internal.AcquireStorageLock(folder, "delete", time.Hour, false)
+	assert.NoError(t, err)
+	putStorageLock(t, folder, time.Now().Add(time.Hour))
+
+	assert.NoError(t, lock.Release())
+	exists, err := folder.Exists(internal.StorageLockObjectName)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestWithConfiguredStorageLock_DisabledByDefault(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	err := internal.WithConfiguredStorageLock(folder, "delete", false, func() error {
+		exists, err := folder.Exists(internal.StorageLockObjectName)
+		assert.False(t, exists)
+		return err
+	})
+	assert.NoError(t, err)
+}
+
+func TestWithConfiguredStorageLock_RenewsLockDuringLongOperation(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	withSettings(t, map[string]string{internal.StorageLockTTLSetting: "150ms"}, func() {
+		err := internal.WithConfiguredStorageLock(folder, "backup-push", false, func() error {
+			time.Sleep(400 * time.Millisecond)
+			_, err := internal.AcquireStorageLock(folder, "delete", time.Hour, false)
+			assert.IsType(t, internal.StorageLockedError{}, err)
+			return nil
+		})
+		assert.NoError(t, err)
+	})
+	exists, err := folder.Exists(internal.StorageLockObjectName)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestWithConfiguredStorageLock_ReleasesLockWhenOperationFails(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	withSettings(t, map[string]string{internal.StorageLockTTLSetting: "1h"}, func() {
+		err := internal.WithConfiguredStorageLock(folder, "backup-push", false, func() error {
+			return errors.New("backup failed")
+		})
+		assert.EqualError(t, err, "backup failed")
+	})
+	exists, err := folder.Exists(internal.StorageLockObjectName)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -10,14 +10,25 @@ import (
 type StorageReaderMaker struct {
 	Folder       storage.Folder
 	RelativePath string
+	// ExpectedSize is the size of object in the listing, readers fail with TruncatedCopySourceError
+	// if object ends earlier. It is unknownObjectSize if the object wasn't listed.
+	ExpectedSize int64
 }
 
 func newStorageReaderMaker(folder storage.Folder, relativePath string) *StorageReaderMaker {
-	return &StorageReaderMaker{folder, relativePath}
+	return &StorageReaderMaker{folder, relativePath, unknownObjectSize}
+}
+
+func newListedStorageReaderMaker(folder storage.Folder, object storage.Object) *StorageReaderMaker {
+	return &StorageReaderMaker{folder, object.GetName(), object.GetSize()}
 }
 
 func (readerMaker *StorageReaderMaker) Path() string { return readerMaker.RelativePath }
 
 func (readerMaker *StorageReaderMaker) Reader() (io.ReadCloser, error) {
-	return readerMaker.Folder.ReadObject(readerMaker.RelativePath)
+	readCloser, err := readObjectOfSize(readerMaker.Folder, readerMaker.RelativePath, readerMaker.ExpectedSize)
+	if err != nil {
+		return nil, err
+	}
+	return NewFetchLimitReadCloser(readCloser), nil
 }
@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+)
+
+// reconcileCopy is a planned copy with indexes of its source and destination folders
+type reconcileCopy struct {
+	info CopyingInfo
+	from int
+	to   int
+}
+
+// BuildReconcilePlan returns infos copying every object missing in some of folders
+// from the first folder which has it, so that all folders contain the same object names.
+// Objects present in several folders are not compared, use copy --repair for that.
+func BuildReconcilePlan(folders []storage.Folder) ([]CopyingInfo, error) {
+	copies, err := buildReconcileCopies(folders)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]CopyingInfo, 0, len(copies))
+	for _, reconcileCopy := range copies {
+		infos = append(infos, reconcileCopy.info)
+	}
+	return infos, nil
+}
+
+func buildReconcileCopies(folders []storage.Folder) ([]reconcileCopy, error) {
+	if len(folders) < 2 {
+		return nil, errors.New("at least two storages are needed to reconcile")
+	}
+	protectedGlobs, err := getProtectedObjectGlobs()
+	if err != nil {
+		return nil, err
+	}
+	folderNames := make([]map[string]bool, len(folders))
+	sources := make(map[string]int)
+	objects := make(map[string]storage.Object)
+	for i, folder := range folders {
+		folderObjects, err := storage.ListFolderRecursively(folder)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list '%s'", folder.GetPath())
+		}
+		folderNames[i] = make(map[string]bool, len(folderObjects))
+		for _, object := range FilterProtectedObjects(folderObjects, protectedGlobs) {
+			folderNames[i][object.GetName()] = true
+			if _, ok := objects[object.GetName()]; !ok {
+				objects[object.GetName()] = object
+				sources[object.GetName()] = i
+			}
+		}
+	}
+	names := make([]string, 0, len(objects))
+	for name := range objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	copies := make([]reconcileCopy, 0)
+	for i, folder := range folders {
+		for _, name := range names {
+			if !folderNames[i][name] {
+				source := sources[name]
+				copies = append(copies, reconcileCopy{CopyingInfo{objects[name], folders[source], folder, name}, source, i})
+			}
+		}
+	}
+	return copies, nil
+}
+
+// HandleStorageReconcile prints the reconcile plan as "<source> -> <destination>: <object>" lines,
+// where storages are named by folderNames, e.g. their config files, and copies missing objects unless dryRun is set
+func HandleStorageReconcile(folders []storage.Folder, folderNames []string, dryRun bool, output io.Writer) error {
+	if len(folderNames) != len(folders) {
+		return errors.Errorf("%d names are given for %d storages", len(folderNames), len(folders))
+	}
+	copies, err := buildReconcileCopies(folders)
+	if err != nil {
+		return err
+	}
+	infos := make([]CopyingInfo, 0, len(copies))
+	for _, reconcileCopy := range copies {
+		_, err = fmt.Fprintf(output, "%s -> %s: %s\n",
+			folderNames[reconcileCopy.from], folderNames[reconcileCopy.to], reconcileCopy.info.TargetName)
+		if err != nil {
+			return err
+		}
+		infos = append(infos, reconcileCopy.info)
+	}
+	if dryRun || len(infos) == 0 {
+		tracelog.InfoLogger.Printf("%d objects are missing, nothing was copied\n", len(infos))
+		return nil
+	}
+	_, err = StartCopy(infos)
+	return err
+}
@@ -0,0 +1,62 @@
+package internal_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+)
+
+func makeDivergentFolders(t *testing.T) []storage.Folder {
+	contents := [][]string{
+		{"basebackups_005/a", "wal_005/1", "wal_005/2"},
+		{"basebackups_005/a", "wal_005/3"},
+		{"wal_005/1", "wal_005/4"},
+	}
+	folders := make([]storage.Folder, 0, len(contents))
+	for _, names := range contents {
+		folder := testtools.MakeDefaultInMemoryStorageFolder()
+		for _, name := range names {
+			require.NoError(t, folder.PutObject(name, strings.NewReader(name)))
+		}
+		folders = append(folders, folder)
+	}
+	return folders
+}
+
+func TestHandleStorageReconcile_FoldersConverge(t *testing.T) {
+	folders := makeDivergentFolders(t)
+
+	output := new(bytes.Buffer)
+	require.NoError(t, internal.HandleStorageReconcile(folders, []string{"a", "b", "c"}, false, output))
+
+	assert.Equal(t, 8, strings.Count(output.String(), "\n"))
+	expected := []string{"basebackups_005/a", "wal_005/1", "wal_005/2", "wal_005/3", "wal_005/4"}
+	for _, folder := range folders {
+		assert.ElementsMatch(t, expected, getFolderObjectNames(t, folder))
+	}
+	infos, err := internal.BuildReconcilePlan(folders)
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+}
+
+func TestHandleStorageReconcile_DryRunCopiesNothing(t *testing.T) {
+	folders := makeDivergentFolders(t)
+
+	output := new(bytes.Buffer)
+	require.NoError(t, internal.HandleStorageReconcile(folders, []string{"a", "b", "c"}, true, output))
+
+	assert.Contains(t, output.String(), "b -> a: wal_005/3\n")
+	assert.Contains(t, output.String(), "c -> a: wal_005/4\n")
+	assert.ElementsMatch(t, []string{"wal_005/1", "wal_005/4"}, getFolderObjectNames(t, folders[2]))
+}
+
+func TestBuildReconcilePlan_NeedsTwoStorages(t *testing.T) {
+	_, err := internal.BuildReconcilePlan([]storage.Folder{testtools.MakeDefaultInMemoryStorageFolder()})
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/tracelog"
+)
+
+type UnknownTablespaceError struct {
+	error
+}
+
+func newUnknownTablespaceError(oid string, known []string) UnknownTablespaceError {
+	return UnknownTablespaceError{errors.Errorf("tablespace '%s' is not found in backup, backup tablespaces are: %v",
+		oid, known)}
+}
+
+func (err UnknownTablespaceError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// getTablespaceOid returns OID of tablespace which contains file of backup, files are named like /pg_tblspc/16384/...
+func getTablespaceOid(filePath string) (string, bool) {
+	prefix := "/" + TablespaceFolder + "/"
+	if !strings.HasPrefix(filePath, prefix) {
+		return "", false
+	}
+	oid := strings.SplitN(strings.TrimPrefix(filePath, prefix), "/", 2)[0]
+	return oid, oid != ""
+}
+
+// ExcludeTablespaceFiles removes files of tablespaces with given OIDs from filesToUnwrap.
+// Every OID has to be a tablespace of the backup.
+func ExcludeTablespaceFiles(filesToUnwrap map[string]bool, excludedOids []string) (map[string]bool, error) {
+	if len(excludedOids) == 0 {
+		return filesToUnwrap, nil
+	}
+	if filesToUnwrap == nil {
+		return nil, errors.New("backup has no file list, tablespaces can't be excluded")
+	}
+	knownOids := make(map[string]bool)
+	for file := range filesToUnwrap {
+		if oid, ok := getTablespaceOid(file); ok {
+			knownOids[oid] = true
+		}
+	}
+	excluded := make(map[string]bool, len(excludedOids))
+	for _, oid := range excludedOids {
+		if !knownOids[oid] {
+			known := make([]string, 0, len(knownOids))
+			for knownOid := range knownOids {
+				known = append(known, knownOid)
+			}
+			sort.Strings(known)
+			return nil, newUnknownTablespaceError(oid, known)
+		}
+		excluded[oid] = true
+	}
+	result := make(map[string]bool, len(filesToUnwrap))
+	for file := range filesToUnwrap {
+		if oid, ok := getTablespaceOid(file); ok && excluded[oid] {
+			continue
+		}
+		result[file] = true
+	}
+	tracelog.InfoLogger.Printf("Excluded %d files of tablespaces %v\n", len(filesToUnwrap)-len(result), excludedOids)
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/wal-g/internal"
+)
+
+var tablespaceBackupFiles = map[string]bool{
+	"/global/pg_control":                        true,
+	"/base/1/1259":                              true,
+	"/pg_tblspc/16384/PG_12_201909212/16385/1":  true,
+	"/pg_tblspc/16384/PG_12_201909212/16385/2":  true,
+	"/pg_tblspc/16390/PG_12_201909212/16391/10": true,
+}
+
+func TestExcludeTablespaceFiles(t *testing.T) {
+	files, err := internal.ExcludeTablespaceFiles(tablespaceBackupFiles, []string{"16384"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{
+		"/global/pg_control":                        true,
+		"/base/1/1259":                              true,
+		"/pg_tblspc/16390/PG_12_201909212/16391/10": true,
+	}, files)
+}
+
+func TestExcludeTablespaceFiles_NothingExcluded(t *testing.T) {
+	files, err := internal.ExcludeTablespaceFiles(tablespaceBackupFiles, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, tablespaceBackupFiles, files)
+}
+
+func TestExcludeTablespaceFiles_UnknownTablespace(t *testing.T) {
+	_, err := internal.ExcludeTablespaceFiles(tablespaceBackupFiles, []string{"16384", "20000"})
+	assert.IsType(t, internal.UnknownTablespaceError{}, err)
+	assert.Contains(t, err.Error(), "[16384 16390]")
+}
+
+func TestExcludeTablespaceFiles_NoFileList(t *testing.T) {
+	_, err := internal.ExcludeTablespaceFiles(internal.UnwrapAll, []string{"16384"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/internal/compression"
+	"github.com/wal-g/wal-g/utility"
+)
+
+// WalArchiveCheckResult describes problems of WAL archive found by CheckWalArchive
+type WalArchiveCheckResult struct {
+	SegmentsCount int `json:"segments_count"`
+	// FirstGap is the name of the first missing segment between segments of the same timeline
+	FirstGap           string   `json:"first_gap,omitempty"`
+	UnreadableSegments []string `json:"unreadable_segments,omitempty"`
+}
+
+func (result WalArchiveCheckResult) IsOk() bool {
+	return result.FirstGap == "" && len(result.UnreadableSegments) == 0
+}
+
+type WalArchiveCheckError struct {
+	error
+}
+
+func newWalArchiveCheckError() WalArchiveCheckError {
+	return WalArchiveCheckError{errors.New("WAL archive has missing or unreadable segments")}
+}
+
+func (err WalArchiveCheckError) Error() string {
+	return fmt.Sprintf(tracelog.GetErrorFormatter(), err.error)
+}
+
+// CheckWalArchive looks for gaps in sequences of WAL segments of every timeline in walFolder.
+// Switching to another timeline is not a gap. If readSegments is set, every segment is downloaded,
+// decrypted and decompressed, segments which fail or are empty are reported as unreadable.
+func CheckWalArchive(walFolder storage.Folder, readSegments bool) (WalArchiveCheckResult, error) {
+	objects, _, err := walFolder.ListFolder()
+	if err != nil {
+		return WalArchiveCheckResult{}, err
+	}
+	segmentFiles := make(map[WalSegmentDescription]string)
+	for _, object := range objects {
+		timeline, segmentNo, err := ParseWALFilename(utility.TrimFileExtension(object.GetName()))
+		if _, ok := err.(NotWalFilenameError); ok {
+			continue
+		}
+		if err != nil {
+			return WalArchiveCheckResult{}, err
+		}
+		segmentFiles[WalSegmentDescription{Timeline: timeline, Number: WalSegmentNo(segmentNo)}] = object.GetName()
+	}
+	segments := make([]WalSegmentDescription, 0, len(segmentFiles))
+	for segment := range segmentFiles {
+		segments = append(segments, segment)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		if segments[i].Timeline != segments[j].Timeline {
+			return segments[i].Timeline < segments[j].Timeline
+		}
+		return segments[i].Number < segments[j].Number
+	})
+
+	result := WalArchiveCheckResult{SegmentsCount: len(segments), UnreadableSegments: make([]string, 0)}
+	for i := 1; i < len(segments) && result.FirstGap == ""; i++ {
+		previous := segments[i-1]
+		if previous.Timeline == segments[i].Timeline && previous.Number.next() != segments[i].Number {
+			result.FirstGap = previous.Number.next().getFilename(previous.Timeline)
+		}
+	}
+	if !readSegments {
+		return result, nil
+	}
+	for _, segment := range segments {
+		fileName := segmentFiles[segment]
+		err = checkWalSegmentReadable(walFolder, fileName)
+		if err != nil {
+			tracelog.WarningLogger.Printf("WAL segment '%s' is unreadable: %v\n", fileName, err)
+			result.UnreadableSegments = append(result.UnreadableSegments, fileName)
+		}
+	}
+	return result, nil
+}
+
+func checkWalSegmentReadable(walFolder storage.Folder, fileName string) error {
+	decompressor := compression.FindDecompressor(utility.GetFileExtension(fileName))
+	if decompressor == nil {
+		return errors.Errorf("unknown compression of '%s'", fileName)
+	}
+	archiveReader, err := walFolder.ReadObject(fileName)
+	if err != nil {
+		return err
+	}
+	defer utility.LoggedClose(archiveReader, "")
+	reader, writer := io.Pipe()
+	go func() {
+		err := DecompressDecryptBytes(&EmptyWriteIgnorer{writer}, archiveReader, decompressor)
+		_ = writer.CloseWithError(err)
+	}()
+	defer utility.LoggedClose(reader, "")
+	size, err := io.Copy(ioutil.Discard, reader)
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		return errors.New("segment is empty")
+	}
+	return nil
+}
+
+// HandleWalArchiveCheck prints the result of CheckWalArchive as JSON and fails if there are problems
+func HandleWalArchiveCheck(rootFolder storage.Folder, readSegments bool, output io.Writer) error {
+	result, err := CheckWalArchive(rootFolder.GetSubFolder(utility.WalPath), readSegments)
+	if err != nil {
+		return err
+	}
+	err = WriteAsJson(result, output, true)
+	if err != nil {
+		return err
+	}
+	if !result.IsOk() {
+		return newWalArchiveCheckError()
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package internal_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/internal/compression"
+	"github.com/wal-g/wal-g/internal/compression/lz4"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+func putCompressedWalSegment(t *testing.T, walFolder storage.Folder, name string) {
+	var compressed bytes.Buffer
+	writer := compression.Compressors[lz4.AlgorithmName].NewWriter(&compressed)
+	_, err := writer.Write([]byte(strings.Repeat(name, 10)))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, walFolder.PutObject(name+"."+lz4.FileExtension, &compressed))
+}
+
+func TestCheckWalArchive_ReportsFirstGap(t *testing.T) {
+	walFolder := testtools.MakeDefaultInMemoryStorageFolder().GetSubFolder(utility.WalPath)
+	for _, name := range []string{"000000010000000000000001", "000000010000000000000002",
+		"000000010000000000000004", "000000010000000000000006",
+		"000000020000000000000006", "000000020000000000000007"} {
+		putCompressedWalSegment(t, walFolder, name)
+	}
+
+	result, err := internal.CheckWalArchive(walFolder, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 6, result.SegmentsCount)
+	assert.Equal(t, "000000010000000000000003", result.FirstGap)
+	assert.Empty(t, result.UnreadableSegments)
+	assert.False(t, result.IsOk())
+}
+
+func TestCheckWalArchive_ReportsUnreadableSegment(t *testing.T) {
+	walFolder := testtools.MakeDefaultInMemoryStorageFolder().GetSubFolder(utility.WalPath)
+	putCompressedWalSegment(t, walFolder, "000000010000000000000001")
+	require.NoError(t, walFolder.PutObject("000000010000000000000002."+lz4.FileExtension,
+		strings.NewReader("not an lz4 stream")))
+	putCompressedWalSegment(t, walFolder, "000000010000000000000003")
+
+	result, err := internal.CheckWalArchive(walFolder, true)
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.FirstGap)
+	assert.Equal(t, []string{"000000010000000000000002." + lz4.FileExtension}, result.UnreadableSegments)
+}
+
+func TestHandleWalArchiveCheck_HealthyArchive(t *testing.T) {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	walFolder := folder.GetSubFolder(utility.WalPath)
+	putCompressedWalSegment(t, walFolder, "000000010000000000000001")
+	putCompressedWalSegment(t, walFolder, "000000010000000000000002")
+
+	output := new(bytes.Buffer)
+	err := internal.HandleWalArchiveCheck(folder, true, output)
+
+	assert.NoError(t, err)
+	assert.Contains(t, output.String(), `"segments_count": 2`)
+}
@@ -241,13 +241,22 @@ func DownloadAndDecompressStorageFile(folder storage.Folder, fileName string) (i
 	return nil, newArchiveNonExistenceError(fileName)
 }
 
-// TODO : unit tests
-// downloadWALFileTo downloads a file and writes it to local file
+// DownloadWALFileTo downloads a file and writes it to local file.
+// WAL segments shorter than WalSegmentSize are removed and downloaded again, see retryTruncatedRead.
 func DownloadWALFileTo(folder storage.Folder, walFileName string, dstPath string) error {
-	reader, err := DownloadAndDecompressStorageFile(folder, walFileName)
-	if err != nil {
+	return retryTruncatedRead(func() error {
+		reader, err := DownloadAndDecompressStorageFile(folder, walFileName)
+		if err != nil {
+			return err
+		}
+		defer utility.LoggedClose(reader, "")
+		if isWalFilename(walFileName) {
+			reader = newSizeCheckingReader(reader, walFileName, int64(WalSegmentSize))
+		}
+		err = ioextensions.CreateFileWith(dstPath, reader)
+		if _, isTruncated := errors.Cause(err).(TruncatedCopySourceError); isTruncated {
+			_ = os.Remove(dstPath)
+		}
 		return err
-	}
-	defer utility.LoggedClose(reader, "")
-	return ioextensions.CreateFileWith(dstPath, reader)
+	})
 }
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/tracelog"
+	"github.com/wal-g/wal-g/utility"
+)
+
+const walFileNameLength = 24
+
+// FindWalPruneCutoff returns the first WAL segment needed by the oldest backup in storage.
+// Segments before it are not needed to restore any backup. If metadata of some backup
+// can't be fetched, the cutoff is not known and an error is returned.
+func FindWalPruneCutoff(rootFolder storage.Folder) (WalSegmentNo, error) {
+	backups, err := getBackups(rootFolder)
+	if err != nil {
+		return 0, err
+	}
+	baseBackupFolder := rootFolder.GetSubFolder(utility.BaseBackupPath)
+	cutoff := WalSegmentNo(math.MaxUint64)
+	for _, backupTime := range backups {
+		if backupTime.BackupName == "" {
+			continue
+		}
+		meta, err := NewBackup(baseBackupFolder, backupTime.BackupName).fetchMeta()
+		if err != nil {
+			return 0, errors.Wrapf(err, "can't find WAL needed by backup '%s'", backupTime.BackupName)
+		}
+		startSegmentNo := newWalSegmentNo(meta.StartLsn)
+		if startSegmentNo < cutoff {
+			cutoff = startSegmentNo
+		}
+	}
+	if cutoff == WalSegmentNo(math.MaxUint64) {
+		return 0, NewNoBackupsFoundError()
+	}
+	return cutoff, nil
+}
+
+// getWalObjectsBefore selects WAL segments and their .backup and .partial files older than cutoff
+// on any timeline. Timeline history files are never selected.
+func getWalObjectsBefore(walObjects []storage.Object, cutoff WalSegmentNo) []storage.Object {
+	selected := make([]storage.Object, 0)
+	for _, object := range walObjects {
+		name := object.GetName()
+		if len(name) < walFileNameLength {
+			continue
+		}
+		segmentNo, err := newWalSegmentNoFromFilename(name[:walFileNameLength])
+		if err != nil || segmentNo >= cutoff {
+			continue
+		}
+		selected = append(selected, object)
+	}
+	return selected
+}
+
+// HandleWalPrune deletes WAL which is older than the oldest backup and writes names of deleted objects to output.
+// With dryRun nothing is deleted.
+func HandleWalPrune(rootFolder storage.Folder, dryRun bool, output io.Writer) error {
+	cutoff, err := FindWalPruneCutoff(rootFolder)
+	if err != nil {
+		return err
+	}
+	walFolder := rootFolder.GetSubFolder(utility.WalPath)
+	walObjects, _, err := walFolder.ListFolder()
+	if err != nil {
+		return err
+	}
+	toDelete := getWalObjectsBefore(walObjects, cutoff)
+	names := make([]string, 0, len(toDelete))
+	for _, object := range toDelete {
+		names = append(names, object.GetName())
+		if _, err = fmt.Fprintln(output, object.GetName()); err != nil {
+			return err
+		}
+	}
+	if dryRun {
+		tracelog.InfoLogger.Printf("Dry run: %d WAL objects would be deleted\n", len(names))
+		return nil
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	err = walFolder.DeleteObjects(names)
+	if err != nil {
+		return errors.Wrap(err, "failed to delete WAL")
+	}
+	tracelog.InfoLogger.Printf("Deleted %d WAL objects\n", len(names))
+	return nil
+}
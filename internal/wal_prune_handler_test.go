@@ -0,0 +1,93 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wal-g/storages/storage"
+	"github.com/wal-g/wal-g/internal"
+	"github.com/wal-g/wal-g/testtools"
+	"github.com/wal-g/wal-g/utility"
+)
+
+func putPruneTestBackup(t *testing.T, folder storage.Folder, backupName string, startLsn uint64) {
+	baseBackupFolder := folder.GetSubFolder(utility.BaseBackupPath)
+	assert.NoError(t, baseBackupFolder.PutObject(backupName+utility.SentinelSuffix, strings.NewReader("{}")))
+	metaBytes, err := json.Marshal(internal.ExtendedMetadataDto{StartLsn: startLsn})
+	assert.NoError(t, err)
+	assert.NoError(t, baseBackupFolder.PutObject(backupName+"/"+utility.MetadataFileName, bytes.NewReader(metaBytes)))
+}
+
+func preparePruneTestFolder(t *testing.T) storage.Folder {
+	folder := testtools.MakeDefaultInMemoryStorageFolder()
+	putPruneTestBackup(t, folder, "base_000000010000000000000004", 4*internal.WalSegmentSize+40)
+	putPruneTestBackup(t, folder, "base_000000020000000000000007", 7*internal.WalSegmentSize+40)
+	walFolder := folder.GetSubFolder(utility.WalPath)
+	for _, name := range []string{
+		"000000010000000000000002.lz4",
+		"000000010000000000000003.lz4",
+		"000000010000000000000003.00000028.backup.lz4",
+		"000000010000000000000004.lz4",
+		"000000010000000000000005.lz4",
+		"00000002.history.lz4",
+		"000000020000000000000007.lz4",
+	} {
+		assert.NoError(t, walFolder.PutObject(name, &bytes.Buffer{}))
+	}
+	return folder
+}
+
+func TestFindWalPruneCutoff_OldestBackup(t *testing.T) {
+	cutoff, err := internal.FindWalPruneCutoff(preparePruneTestFolder(t))
+	assert.NoError(t, err)
+	assert.Equal(t, internal.WalSegmentNo(4), cutoff)
+}
+
+func TestFindWalPruneCutoff_NoBackups(t *testing.T) {
+	_, err := internal.FindWalPruneCutoff(testtools.MakeDefaultInMemoryStorageFolder())
+	assert.Error(t, err)
+}
+
+func TestFindWalPruneCutoff_MissingMetadata(t *testing.T) {
+	folder := preparePruneTestFolder(t)
+	err := folder.GetSubFolder(utility.BaseBackupPath).PutObject("base_000000010000000000000001"+utility.SentinelSuffix,
+		strings.NewReader("{}"))
+	assert.NoError(t, err)
+
+	_, err = internal.FindWalPruneCutoff(folder)
+	assert.Error(t, err)
+}
+
+func TestHandleWalPrune_DryRun(t *testing.T) {
+	folder := preparePruneTestFolder(t)
+	var output bytes.Buffer
+
+	err := internal.HandleWalPrune(folder, true, &output)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"000000010000000000000002.lz4",
+		"000000010000000000000003.lz4",
+		"000000010000000000000003.00000028.backup.lz4",
+	}, strings.Fields(output.String()))
+	exists, err := folder.GetSubFolder(utility.WalPath).Exists("000000010000000000000002.lz4")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestHandleWalPrune_DeletesOnlyUnneededWal(t *testing.T) {
+	folder := preparePruneTestFolder(t)
+
+	err := internal.HandleWalPrune(folder, false, &bytes.Buffer{})
+	assert.NoError(t, err)
+	walObjects, _, err := folder.GetSubFolder(utility.WalPath).ListFolder()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"000000010000000000000004.lz4",
+		"000000010000000000000005.lz4",
+		"00000002.history.lz4",
+		"000000020000000000000007.lz4",
+	}, getObjectNames(walObjects))
+}
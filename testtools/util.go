@@ -58,6 +58,8 @@ func CreateMockStorageFolder() storage.Folder {
 	subFolder := folder.GetSubFolder(utility.BaseBackupPath)
 	subFolder.PutObject("base_123_backup_stop_sentinel.json", &bytes.Buffer{})
 	subFolder.PutObject("base_456_backup_stop_sentinel.json", strings.NewReader("{}"))
+	// memory storage timestamps are rounded up to microseconds, so wait to make the last put the newest
+	time.Sleep(time.Millisecond)
 	subFolder.PutObject("base_000_backup_stop_sentinel.json", &bytes.Buffer{}) // last put
 	subFolder.PutObject("base_123312", &bytes.Buffer{})                        // not a sentinel
 	subFolder.PutObject("base_321/nop", &bytes.Buffer{})